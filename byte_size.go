@@ -0,0 +1,123 @@
+package october
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// A size in bytes, decoded from human readable sizes such as "64MiB", "1.5GB" or "512"
+type ByteSize uint64
+
+const (
+	Byte ByteSize = 1
+
+	KB ByteSize = 1000 * Byte
+	MB          = 1000 * KB
+	GB          = 1000 * MB
+	TB          = 1000 * GB
+
+	KiB ByteSize = 1024 * Byte
+	MiB          = 1024 * KiB
+	GiB          = 1024 * MiB
+	TiB          = 1024 * GiB
+)
+
+var byteSizeUnits = map[string]ByteSize{
+	"":    Byte,
+	"B":   Byte,
+	"K":   KB,
+	"KB":  KB,
+	"M":   MB,
+	"MB":  MB,
+	"G":   GB,
+	"GB":  GB,
+	"T":   TB,
+	"TB":  TB,
+	"KI":  KiB,
+	"KIB": KiB,
+	"MI":  MiB,
+	"MIB": MiB,
+	"GI":  GiB,
+	"GIB": GiB,
+	"TI":  TiB,
+	"TIB": TiB,
+}
+
+// Parse a human readable size. Units are case insensitive, KB/MB/GB/TB are powers of 1000, KiB/MiB/GiB/TiB powers of 1024.
+// Sizes are plain decimal numbers, negative sizes and exponents such as 1e6 are rejected.
+func ParseByteSize(s string) (ByteSize, error) {
+	s = strings.TrimSpace(s)
+
+	if strings.HasPrefix(s, "-") {
+		return 0, fmt.Errorf("invalid byte size %q: must not be negative", s)
+	}
+
+	split := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if split < 0 {
+		split = len(s)
+	}
+
+	number, unit := s[:split], strings.ToUpper(strings.TrimSpace(s[split:]))
+
+	multiplier, ok := byteSizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid byte size %q: unknown unit %q", s, unit)
+	}
+
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte size %q", s)
+	}
+
+	size := value * float64(multiplier)
+	if size >= math.MaxUint64 {
+		return 0, fmt.Errorf("invalid byte size %q: too large", s)
+	}
+
+	return ByteSize(size), nil
+}
+
+// Formats the size in the binary or decimal unit representing it exactly with the smallest number,
+// e.g. 64MiB, 64MB, or 1500MB for 1.5GB
+func (b ByteSize) String() string {
+	units := []struct {
+		name string
+		size ByteSize
+	}{
+		{"TiB", TiB},
+		{"TB", TB},
+		{"GiB", GiB},
+		{"GB", GB},
+		{"MiB", MiB},
+		{"MB", MB},
+		{"KiB", KiB},
+		{"KB", KB},
+	}
+
+	name, count := "B", b
+	for _, unit := range units {
+		if b >= unit.size && b%unit.size == 0 && b/unit.size < count {
+			name, count = unit.name, b/unit.size
+		}
+	}
+
+	return fmt.Sprintf("%d%s", uint64(count), name)
+}
+
+func (b ByteSize) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	size, err := ParseByteSize(string(text))
+	if err != nil {
+		return err
+	}
+
+	*b = size
+	return nil
+}
@@ -0,0 +1,77 @@
+package october
+
+import (
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    ByteSize
+		wantErr bool
+	}{
+		{"512", 512, false},
+		{"512B", 512, false},
+		{" 64 MiB ", 64 * MiB, false},
+		{"64mib", 64 * MiB, false},
+		{"64Mi", 64 * MiB, false},
+		{"64MB", 64 * MB, false},
+		{"64M", 64 * MB, false},
+		{"1.5GB", 1500 * MB, false},
+		{"1.5GiB", 1536 * MiB, false},
+		{"2TB", 2 * TB, false},
+		{"0", 0, false},
+		{"", 0, true},
+		{"MB", 0, true},
+		{"-1", 0, true},
+		{"-1MB", 0, true},
+		{"1e6", 0, true},
+		{"1E3MB", 0, true},
+		{"1.2.3MB", 0, true},
+		{"64XB", 0, true},
+		{"100000000TiB", 0, true},
+	}
+
+	for _, test := range tests {
+		got, err := ParseByteSize(test.s)
+		if (err != nil) != test.wantErr {
+			t.Errorf("ParseByteSize(%q) error %v, want error %t", test.s, err, test.wantErr)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("ParseByteSize(%q) = %d, want %d", test.s, got, test.want)
+		}
+	}
+}
+
+func TestByteSizeString(t *testing.T) {
+	tests := []struct {
+		size ByteSize
+		want string
+	}{
+		{0, "0B"},
+		{512, "512B"},
+		{1000, "1KB"},
+		{1024, "1KiB"},
+		{64 * MiB, "64MiB"},
+		{64 * MB, "64MB"},
+		{1500 * MB, "1500MB"},
+		{1536 * MiB, "1536MiB"},
+		{1000 * KiB, "1000KiB"},
+		{3 * TB, "3TB"},
+		{2 * TiB, "2TiB"},
+		{1001, "1001B"},
+	}
+
+	for _, test := range tests {
+		if got := test.size.String(); got != test.want {
+			t.Errorf("ByteSize(%d).String() = %q, want %q", uint64(test.size), got, test.want)
+		}
+
+		// Every rendered size parses back to the same size
+		parsed, err := ParseByteSize(test.want)
+		if err != nil || parsed != test.size {
+			t.Errorf("ParseByteSize(%q) = %d, %v, want %d", test.want, parsed, err, uint64(test.size))
+		}
+	}
+}
@@ -0,0 +1,225 @@
+// october-config prints a reference of the OCTOBER_* environment variables read by October,
+// and manages encrypted config values
+//
+// Usage:
+//
+//	october-config reference [-format markdown|env]
+//	october-config keygen
+//	october-config encrypt [-key key] [-key-file path] [value]
+//	october-config decrypt [-key keys] [-key-file path] value
+//	october-config rotate -new-key key [-key keys] [-key-file path] [-w] file
+//
+// Keys are base64 encoded, and default to OCTOBER_CONFIG_KEY or OCTOBER_CONFIG_KEY_FILE.
+// encrypt reads the value from stdin when it isn't given as an argument.
+//
+// Services documenting their own config structs can call october.ConfigReferenceFor with
+// october.WriteConfigMarkdown or october.WriteEnvExample.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/willtrking/october"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "reference":
+		err = reference(os.Args[2:])
+	case "keygen":
+		err = keygen()
+	case "encrypt":
+		err = encrypt(os.Args[2:])
+	case "decrypt":
+		err = decrypt(os.Args[2:])
+	case "rotate":
+		err = rotate(os.Args[2:])
+	case "-h", "-help", "--help", "help":
+		usage()
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage:")
+	fmt.Fprintln(os.Stderr, "    october-config reference [-format markdown|env]")
+	fmt.Fprintln(os.Stderr, "    october-config keygen")
+	fmt.Fprintln(os.Stderr, "    october-config encrypt [-key key] [-key-file path] [value]")
+	fmt.Fprintln(os.Stderr, "    october-config decrypt [-key keys] [-key-file path] value")
+	fmt.Fprintln(os.Stderr, "    october-config rotate -new-key key [-key keys] [-key-file path] [-w] file")
+}
+
+func reference(args []string) error {
+	fs := flag.NewFlagSet("reference", flag.ExitOnError)
+	format := fs.String("format", "markdown", "Output format, markdown or env")
+	fs.Parse(args)
+
+	refs := october.OctoberConfigReference()
+
+	switch *format {
+	case "markdown", "md":
+		return october.WriteConfigMarkdown(os.Stdout, refs)
+	case "env":
+		return october.WriteEnvExample(os.Stdout, refs)
+	}
+
+	return fmt.Errorf("Unknown format %q, expected markdown or env", *format)
+}
+
+func keygen() error {
+	key, err := october.GenerateConfigKey()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(october.EncodeConfigKey(key))
+	return nil
+}
+
+// Adds -key and -key-file flags to fs, returning a function loading the keys after parsing
+func keyFlags(fs *flag.FlagSet) func() ([][]byte, error) {
+	key := fs.String("key", "", "Base64 encoded keys, comma separated (default $OCTOBER_CONFIG_KEY)")
+	keyFile := fs.String("key-file", "", "File holding base64 encoded keys (default $OCTOBER_CONFIG_KEY_FILE)")
+
+	return func() ([][]byte, error) {
+		var keys [][]byte
+		var err error
+
+		switch {
+		case *key != "":
+			keys, err = october.ParseConfigKeys(*key)
+		case *keyFile != "":
+			var contents []byte
+			contents, err = ioutil.ReadFile(*keyFile)
+			if err == nil {
+				keys, err = october.ParseConfigKeys(string(contents))
+			}
+		default:
+			keys, err = october.ConfigKeysFromEnv()
+		}
+
+		if err == nil && len(keys) == 0 {
+			err = fmt.Errorf("No key given, use -key, -key-file or OCTOBER_CONFIG_KEY")
+		}
+
+		return keys, err
+	}
+}
+
+func encrypt(args []string) error {
+	fs := flag.NewFlagSet("encrypt", flag.ExitOnError)
+	loadKeys := keyFlags(fs)
+	fs.Parse(args)
+
+	keys, err := loadKeys()
+	if err != nil {
+		return err
+	}
+
+	value := strings.Join(fs.Args(), " ")
+	if fs.NArg() == 0 {
+		input, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		value = strings.TrimSuffix(string(input), "\n")
+	}
+
+	encrypted, err := october.EncryptConfigValue(keys[0], value)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(encrypted)
+	return nil
+}
+
+func decrypt(args []string) error {
+	fs := flag.NewFlagSet("decrypt", flag.ExitOnError)
+	loadKeys := keyFlags(fs)
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("decrypt expects a single value")
+	}
+
+	keys, err := loadKeys()
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := october.DecryptConfigValue(keys, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(plaintext)
+	return nil
+}
+
+func rotate(args []string) error {
+	fs := flag.NewFlagSet("rotate", flag.ExitOnError)
+	loadKeys := keyFlags(fs)
+	newKey := fs.String("new-key", "", "Base64 encoded key to re-encrypt values with")
+	write := fs.Bool("w", false, "Write the result to the file instead of stdout")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("rotate expects a single file")
+	}
+
+	oldKeys, err := loadKeys()
+	if err != nil {
+		return err
+	}
+
+	newKeys, err := october.ParseConfigKeys(*newKey)
+	if err != nil {
+		return err
+	}
+	if len(newKeys) != 1 {
+		return fmt.Errorf("rotate expects a single -new-key")
+	}
+
+	path := fs.Arg(0)
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	rotated, err := october.RotateEncryptedConfigValues(string(contents), oldKeys, newKeys[0])
+	if err != nil {
+		return err
+	}
+
+	if !*write {
+		_, err = fmt.Print(rotated)
+		return err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, []byte(rotated), info.Mode())
+}
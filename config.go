@@ -3,19 +3,18 @@ package october
 import (
 	"reflect"
 	"strings"
+	"sync"
 
 	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
 const (
-	modeEnvVariable         = "OCTOBER_MODE"
-	portEnvVariable         = "OCTOBER_PORT"
-	gqlPortEnvVariable      = "OCTOBER_GRAPHQL_PORT"
-	grpcPortEnvVariable     = "OCTOBER_GRPC_PORT"
-	tlsBundleCRTEnvVariable = "OCTOBER_TLS_BUNDLE_CRT"
-	tlsKeyEnvVariable       = "OCTOBER_TLS_KEY"
-	configuratorTagName     = "october"
+	octoberEnvPrefix     = "OCTOBER"
+	modeEnvVariable      = "OCTOBER_MODE"
+	configuratorTagName  = "october"
+	configDefaultTagName = "default"
 )
 
 // Generate a new configuratior, prefix may be an empty string
@@ -27,34 +26,122 @@ func NewEnvConfigurator() *Configurator {
 }
 
 type Configurator struct {
+	// Values read into Viper directly, e.g. with ReadConfig or MergeConfigMap, are replaced whenever
+	// ReadConfigFiles, WithProviders or a ConfigWatcher reload loads config files and providers, use a ConfigProvider instead.
+	// Values from Set and SetDefault are kept.
 	Viper *viper.Viper
+
+	mu          sync.Mutex             // Serializes loading and decoding, viper is not safe for concurrent use
+	configFiles []string               // Config files in the order they are layered, see ReadConfigFiles
+	providers   []ConfigProvider       // Providers in the order they are layered, see WithProviders
+	flags       map[string]*pflag.Flag // Flags by config key, see BindFlags
+	strictEnv   StrictEnvMode
+	dotEnvFiles []string                      // See WithDotEnv
+	decodeHooks []mapstructure.DecodeHookFunc // See WithDecodeHooks
+
+	decryptionKeys [][]byte // See WithDecryptionKeys
 }
 
+// Decodes config values into the october tagged struct pointed to by decodeInto, then validates the result.
+// Fields may declare a default with a default tag, e.g. `october:"port" default:"8080"`, used when no other value is set.
+// Any value may instead be read from a file named by the variable suffixed with _FILE, e.g. PREFIX_DB_PASSWORD_FILE=/run/secrets/db
+// Values encrypted with EncryptConfigValue are decrypted, see WithDecryptionKeys.
+// See ValidateConfig for validation rules.
 func (c *Configurator) DecodeEnv(decodeInto interface{}, prefix string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.decode(decodeInto, prefix)
+}
+
+func (c *Configurator) decode(decodeInto interface{}, prefix string) error {
+
+	prefix = strings.TrimSpace(prefix)
+	c.Viper.SetEnvPrefix(prefix)
+
+	if len(c.dotEnvFiles) > 0 {
+		_, err := LoadDotEnvIfLocal(c.dotEnvFiles...)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Values from _FILE variables and flags, applied over viper's settings rather than set in viper,
+	// where they would stick across reloads and shadow every other source
+	overrides := make(map[string]interface{})
+
+	for _, field := range getTaggedConfigFields(decodeInto) {
+		env := configEnvName(prefix, field.Key)
+		c.Viper.BindEnv(field.Key, env)
 
-	c.Viper.SetEnvPrefix(strings.TrimSpace(prefix))
+		if def, ok := field.Field.Tag.Lookup(configDefaultTagName); ok {
+			c.Viper.SetDefault(field.Key, def)
+		}
+
+		err := c.resolveSecretFile(overrides, field.Key, env)
+		if err != nil {
+			return err
+		}
 
-	keys := getTaggedConfigKeys(decodeInto)
-	for _, key := range keys {
-		c.Viper.BindEnv(key)
+		// Flags take precedence over everything, including secret files
+		c.applyFlag(overrides, field.Key)
 	}
 
-	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+	err := checkUnknownEnv(c.strictEnv, prefix, knownConfigEnv(decodeInto, prefix))
+	if err != nil {
+		return err
+	}
+
+	// Decrypt first, so every other hook sees plaintext
+	hooks := append([]mapstructure.DecodeHookFunc{c.decryptHookFunc()}, c.decodeHooks...)
+
+	allocEmbeddedConfigStructs(reflect.ValueOf(decodeInto))
+
+	decoder, err := newConfigDecoder(decodeInto, hooks...)
+	if err != nil {
+		return err
+	}
+
+	settings := c.Viper.AllSettings()
+	mergeConfigMaps(settings, expandConfigKeys(overrides))
+	markDecryptedConfigValues(prefix, "", settings)
+
+	err = decoder.Decode(settings)
+	if err != nil {
+		return err
+	}
+
+	return ValidateConfig(decodeInto, prefix)
+}
+
+func newConfigDecoder(result interface{}, hooks ...mapstructure.DecodeHookFunc) (*mapstructure.Decoder, error) {
+	return mapstructure.NewDecoder(&mapstructure.DecoderConfig{
 		Metadata:         nil,
-		Result:           decodeInto,
+		Result:           result,
 		WeaklyTypedInput: true,
-		DecodeHook: mapstructure.ComposeDecodeHookFunc(
-			mapstructure.StringToTimeDurationHookFunc(),
-			mapstructure.StringToSliceHookFunc(","),
-		),
-		TagName: configuratorTagName,
+		DecodeHook:       mapstructure.ComposeDecodeHookFunc(configDecodeHooks(hooks)...),
+		TagName:          configuratorTagName,
+		Squash:           true,
 	})
+}
 
+// Sets every field of val with a default tag to its default, without reading any other source
+func ApplyConfigDefaults(val interface{}) error {
+	settings := viper.New()
+	for _, field := range getTaggedConfigFields(val) {
+		if def, ok := field.Field.Tag.Lookup(configDefaultTagName); ok {
+			settings.SetDefault(field.Key, def)
+		}
+	}
+
+	allocEmbeddedConfigStructs(reflect.ValueOf(val))
+
+	decoder, err := newConfigDecoder(val)
 	if err != nil {
 		return err
 	}
 
-	return decoder.Decode(c.Viper.AllSettings())
+	return decoder.Decode(settings.AllSettings())
 }
 
 func (c *Configurator) MustDecodeEnv(decodeInto interface{}, prefix string) {
@@ -65,21 +152,146 @@ func (c *Configurator) MustDecodeEnv(decodeInto interface{}, prefix string) {
 	}
 }
 
+// configField is a single october tagged leaf field of a config struct
+type configField struct {
+	Key   string              // Dotted key, e.g. "db.host" for a Host field nested under a DB field
+	Field reflect.StructField // The leaf struct field
+	Index []int               // Field indexes from the root struct, pointers are followed between steps
+}
+
 func getTaggedConfigKeys(val interface{}) []string {
+	var keys []string
+	for _, field := range getTaggedConfigFields(val) {
+		keys = append(keys, field.Key)
+	}
+
+	return keys
+}
+
+// Walks val, recursing into nested structs, pointers to structs and embedded structs.
+// Embedded structs share the namespace of their parent, named struct fields nest their keys under the field's tag.
+// Structs without any october tagged fields of their own (time.Time, etc) are treated as leaves.
+func getTaggedConfigFields(val interface{}) []configField {
 	valType := reflect.TypeOf(val)
-	if valType.Kind() == reflect.Ptr {
+	for valType != nil && valType.Kind() == reflect.Ptr {
 		valType = valType.Elem()
 	}
 
-	var keys []string
-	for i := 0; i < valType.NumField(); i++ {
-		field := valType.Field(i)
+	if valType == nil || valType.Kind() != reflect.Struct {
+		return nil
+	}
+
+	return collectConfigFields(valType, "", nil, map[reflect.Type]bool{})
+}
+
+func collectConfigFields(structType reflect.Type, parentKey string, parentIndex []int, visiting map[reflect.Type]bool) []configField {
+	// Guard against self referencing config types
+	if visiting[structType] {
+		return nil
+	}
+	visiting[structType] = true
+	defer delete(visiting, structType)
+
+	var fields []configField
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+
+		index := make([]int, len(parentIndex)+1)
+		copy(index, parentIndex)
+		index[len(parentIndex)] = i
+
+		if field.Anonymous {
+			if embedded := configStructType(field.Type); embedded != nil {
+				fields = append(fields, collectConfigFields(embedded, parentKey, index, visiting)...)
+				continue
+			}
+		}
 
-		configKey := strings.TrimSpace(field.Tag.Get(configuratorTagName))
-		if configKey != "" {
-			keys = append(keys, configKey)
+		name := configTagName(field)
+		if name == "" {
+			continue
 		}
+
+		key := name
+		if parentKey != "" {
+			key = parentKey + "." + name
+		}
+
+		if nested := configStructType(field.Type); nested != nil {
+			children := collectConfigFields(nested, key, index, visiting)
+			if len(children) > 0 {
+				fields = append(fields, children...)
+				continue
+			}
+		}
+
+		fields = append(fields, configField{Key: key, Field: field, Index: index})
 	}
 
-	return keys
+	return fields
+}
+
+// Allocates nil embedded struct pointers of the struct val points to, recursively.
+// mapstructure only squashes embedded pointers that are already set, so their fields would otherwise be left empty.
+func allocEmbeddedConfigStructs(val reflect.Value) {
+	for val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return
+		}
+		val = val.Elem()
+	}
+
+	if val.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < val.NumField(); i++ {
+		field := val.Type().Field(i)
+		if !field.Anonymous || configStructType(field.Type) == nil {
+			continue
+		}
+
+		value := val.Field(i)
+		if value.Kind() == reflect.Ptr && value.IsNil() {
+			if !value.CanSet() {
+				continue
+			}
+			value.Set(reflect.New(field.Type.Elem()))
+		}
+
+		allocEmbeddedConfigStructs(value)
+	}
+}
+
+// Returns the october tag name of a field, without any tag options
+func configTagName(field reflect.StructField) string {
+	tag := field.Tag.Get(configuratorTagName)
+	if comma := strings.Index(tag, ","); comma >= 0 {
+		tag = tag[:comma]
+	}
+
+	return strings.TrimSpace(tag)
+}
+
+// Returns the struct type of t if it's a struct or pointer to a struct, nil otherwise
+func configStructType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	return t
+}
+
+// Returns the environment variable a config key is bound to, e.g. PREFIX_DB_HOST for "db.host"
+func configEnvName(prefix, key string) string {
+	name := strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+	if prefix != "" {
+		name = strings.ToUpper(prefix) + "_" + name
+	}
+
+	return name
 }
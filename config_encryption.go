@@ -0,0 +1,259 @@
+package october
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+const (
+	// Prefix of encrypted config values, followed by base64 encoded AES-256-GCM nonce and ciphertext
+	EncryptedConfigPrefix = "enc:"
+
+	// Base64 encoded config keys, comma separated. The first key encrypts, all keys are tried when decrypting.
+	configKeyEnvVariable     = "OCTOBER_CONFIG_KEY"
+	configKeyFileEnvVariable = configKeyEnvVariable + secretFileEnvSuffix
+
+	configKeySize = 32
+)
+
+// Matches encrypted values within a config file
+var encryptedConfigValuePattern = regexp.MustCompile(regexp.QuoteMeta(EncryptedConfigPrefix) + `[A-Za-z0-9+/]+=*`)
+
+// Environment variable names of config keys that held encrypted values, redacted by ConfigValues along with secret fields.
+// Keyed by name rather than by decoded struct, so copies of a config are redacted too.
+var decryptedConfigEnv sync.Map
+
+// Returns a new random key for EncryptConfigValue
+func GenerateConfigKey() ([]byte, error) {
+	key := make([]byte, configKeySize)
+	_, err := io.ReadFull(rand.Reader, key)
+
+	return key, err
+}
+
+func EncodeConfigKey(key []byte) string {
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+// Parse comma separated base64 encoded keys
+func ParseConfigKeys(encoded string) ([][]byte, error) {
+	var keys [][]byte
+	for _, part := range strings.Split(encoded, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		key, err := base64.StdEncoding.DecodeString(part)
+		if err != nil {
+			return nil, errors.Wrap(err, "Invalid config key encoding")
+		}
+		if len(key) != configKeySize {
+			return nil, errors.Errorf("Invalid config key length %d, expected %d bytes", len(key), configKeySize)
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// Returns keys from OCTOBER_CONFIG_KEY, or the file named by OCTOBER_CONFIG_KEY_FILE. Returns no keys if neither is set.
+func ConfigKeysFromEnv() ([][]byte, error) {
+	encoded := strings.TrimSpace(os.Getenv(configKeyEnvVariable))
+
+	if encoded == "" {
+		path := strings.TrimSpace(os.Getenv(configKeyFileEnvVariable))
+		if path == "" {
+			return nil, nil
+		}
+
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to read config key from %s", configKeyFileEnvVariable)
+		}
+		encoded = string(contents)
+	}
+
+	return ParseConfigKeys(encoded)
+}
+
+// Encrypt plaintext with key, returning a value prefixed with EncryptedConfigPrefix
+func EncryptConfigValue(key []byte, plaintext string) (string, error) {
+	gcm, err := newConfigGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+
+	return EncryptedConfigPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt a value produced by EncryptConfigValue, trying each key in turn
+func DecryptConfigValue(keys [][]byte, value string) (string, error) {
+	if !IsEncryptedConfigValue(value) {
+		return "", errors.New("Value is not encrypted")
+	}
+
+	if len(keys) == 0 {
+		return "", errors.Errorf("Encrypted config value found without a key, set %s or %s", configKeyEnvVariable, configKeyFileEnvVariable)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(strings.TrimSpace(value), EncryptedConfigPrefix))
+	if err != nil {
+		return "", errors.Wrap(err, "Invalid encrypted config value")
+	}
+
+	for _, key := range keys {
+		gcm, err := newConfigGCM(key)
+		if err != nil {
+			return "", err
+		}
+
+		if len(sealed) < gcm.NonceSize() {
+			return "", errors.New("Invalid encrypted config value")
+		}
+
+		plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+		if err == nil {
+			return string(plaintext), nil
+		}
+	}
+
+	return "", errors.New("Failed to decrypt config value with any key")
+}
+
+func IsEncryptedConfigValue(value string) bool {
+	return strings.HasPrefix(strings.TrimSpace(value), EncryptedConfigPrefix)
+}
+
+// Re-encrypt every encrypted value within text, decrypting with oldKeys and encrypting with newKey
+func RotateEncryptedConfigValues(text string, oldKeys [][]byte, newKey []byte) (string, error) {
+	var rotateErr error
+
+	rotated := encryptedConfigValuePattern.ReplaceAllStringFunc(text, func(value string) string {
+		if rotateErr != nil {
+			return value
+		}
+
+		plaintext, err := DecryptConfigValue(oldKeys, value)
+		if err != nil {
+			rotateErr = err
+			return value
+		}
+
+		encrypted, err := EncryptConfigValue(newKey, plaintext)
+		if err != nil {
+			rotateErr = err
+			return value
+		}
+
+		return encrypted
+	})
+
+	return rotated, rotateErr
+}
+
+// Decrypt encrypted values with keys provided by WithDecryptionKeys, or ConfigKeysFromEnv
+func (c *Configurator) WithDecryptionKeys(keys ...[]byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.decryptionKeys = append(c.decryptionKeys, keys...)
+}
+
+// Returns a hook decrypting encrypted strings before any other hook runs.
+// Keys are only read from the environment once an encrypted value is found, and are read again by every decode,
+// so a rotated OCTOBER_CONFIG_KEY is picked up on reload. Must hold c.mu.
+func (c *Configurator) decryptHookFunc() mapstructure.DecodeHookFuncType {
+	keys := c.decryptionKeys
+	envKeysRead := false
+
+	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if f.Kind() != reflect.String {
+			return data, nil
+		}
+
+		value := reflect.ValueOf(data).String()
+		if !IsEncryptedConfigValue(value) {
+			return data, nil
+		}
+
+		if len(keys) == 0 && !envKeysRead {
+			envKeys, err := ConfigKeysFromEnv()
+			if err != nil {
+				return nil, err
+			}
+			keys, envKeysRead = envKeys, true
+		}
+
+		return DecryptConfigValue(keys, value)
+	}
+}
+
+// Records every key of settings holding an encrypted value, directly or within a list, see decryptedConfigEnv
+func markDecryptedConfigValues(prefix, parentKey string, settings map[string]interface{}) {
+	for name, value := range settings {
+		key := name
+		if parentKey != "" {
+			key = parentKey + "." + name
+		}
+
+		switch value := value.(type) {
+		case map[string]interface{}:
+			markDecryptedConfigValues(prefix, key, value)
+		case string:
+			if IsEncryptedConfigValue(value) {
+				decryptedConfigEnv.Store(configEnvName(prefix, key), true)
+			}
+		case []interface{}:
+			for _, element := range value {
+				if s, ok := element.(string); ok && IsEncryptedConfigValue(s) {
+					decryptedConfigEnv.Store(configEnvName(prefix, key), true)
+					break
+				}
+			}
+		case []string:
+			for _, element := range value {
+				if IsEncryptedConfigValue(element) {
+					decryptedConfigEnv.Store(configEnvName(prefix, key), true)
+					break
+				}
+			}
+		}
+	}
+}
+
+// Returns true if the value of the key bound to env was decrypted
+func isDecryptedConfigEnv(env string) bool {
+	_, ok := decryptedConfigEnv.Load(env)
+	return ok
+}
+
+func newConfigGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,133 @@
+package october
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func testConfigKey(t *testing.T) []byte {
+	t.Helper()
+
+	key, err := GenerateConfigKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key
+}
+
+func testEncrypt(t *testing.T, key []byte, plaintext string) string {
+	t.Helper()
+
+	encrypted, err := EncryptConfigValue(key, plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return encrypted
+}
+
+func TestDecryptConfigValue(t *testing.T) {
+	key, oldKey, otherKey := testConfigKey(t), testConfigKey(t), testConfigKey(t)
+	encrypted := testEncrypt(t, key, "hunter2")
+
+	tests := []struct {
+		name  string
+		keys  [][]byte
+		value string
+		want  string
+		err   bool
+	}{
+		{"single key", [][]byte{key}, encrypted, "hunter2", false},
+		{"rotated keys", [][]byte{oldKey, key}, encrypted, "hunter2", false},
+		{"surrounding space", [][]byte{key}, " " + encrypted + "\n", "hunter2", false},
+		{"empty plaintext", [][]byte{key}, testEncrypt(t, key, ""), "", false},
+		{"wrong key", [][]byte{otherKey}, encrypted, "", true},
+		{"no keys", nil, encrypted, "", true},
+		{"not encrypted", [][]byte{key}, "hunter2", "", true},
+		{"invalid base64", [][]byte{key}, EncryptedConfigPrefix + "!!!", "", true},
+		{"too short", [][]byte{key}, EncryptedConfigPrefix + "AAAA", "", true},
+		{"tampered", [][]byte{key}, encrypted[:len(encrypted)-4] + "AAA=", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			plaintext, err := DecryptConfigValue(test.keys, test.value)
+			if (err != nil) != test.err {
+				t.Fatalf("got error %v, want error %t", err, test.err)
+			}
+			if plaintext != test.want {
+				t.Errorf("got %q, want %q", plaintext, test.want)
+			}
+		})
+	}
+}
+
+func TestRotateEncryptedConfigValues(t *testing.T) {
+	oldKey, newKey := testConfigKey(t), testConfigKey(t)
+	text := "password: " + testEncrypt(t, oldKey, "hunter2") + "\nuser: admin\n"
+
+	rotated, err := RotateEncryptedConfigValues(text, [][]byte{oldKey}, newKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	match := encryptedConfigValuePattern.FindString(rotated)
+	plaintext, err := DecryptConfigValue([][]byte{newKey}, match)
+	if err != nil || plaintext != "hunter2" {
+		t.Errorf("rotated value decrypted to %q, %v", plaintext, err)
+	}
+
+	_, err = DecryptConfigValue([][]byte{oldKey}, match)
+	if err == nil {
+		t.Error("rotated value still decrypts with the old key")
+	}
+}
+
+func TestDecodeEncryptedConfig(t *testing.T) {
+	key, rotatedKey := testConfigKey(t), testConfigKey(t)
+
+	dir := t.TempDir()
+	base := filepath.Join(dir, "config.yaml")
+	writeTestFile(t, base, "user: "+testEncrypt(t, key, "admin")+"\n")
+
+	t.Setenv(configKeyEnvVariable, EncodeConfigKey(key))
+
+	c := NewEnvConfigurator()
+	err := c.ReadConfigFiles(base, LOCAL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var config testSecretConfig
+	err = c.DecodeEnv(&config, "TESTENC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.User != "admin" {
+		t.Errorf("got user %q, want admin", config.User)
+	}
+
+	// User isn't tagged secret, but was decrypted
+	for _, cv := range ConfigValues(&config, "TESTENC") {
+		if cv.Key == "user" && cv.Value != RedactedConfigValue {
+			t.Errorf("decrypted user logged as %q", cv.Value)
+		}
+	}
+
+	// Keys from the environment are read again on every decode
+	writeTestFile(t, base, "user: "+testEncrypt(t, rotatedKey, "root")+"\n")
+	t.Setenv(configKeyEnvVariable, EncodeConfigKey(rotatedKey))
+
+	err = c.loadConfigLayers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.DecodeEnv(&config, "TESTENC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.User != "root" {
+		t.Errorf("got user %q with the rotated key, want root", config.User)
+	}
+}
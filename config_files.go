@@ -0,0 +1,83 @@
+package october
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Read an optional base config file, followed by an optional overlay for the given mode.
+// For a base of config.yaml and mode PROD the overlay is config.PROD.yaml, values in the overlay replace those in the base.
+// Either file may be missing. The format is taken from the file extension (yaml, yml, toml, json).
+// Environment variables bound by DecodeEnv always take precedence over values from config files.
+func (c *Configurator) ReadConfigFiles(base string, mode Mode) error {
+	base = strings.TrimSpace(base)
+	if base == "" {
+		return errors.New("Missing base config file path")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.configFiles = []string{base, configOverlayPath(base, mode)}
+
+	return c.loadConfigLayers()
+}
+
+// Same as ReadConfigFiles, with the mode taken from OCTOBER_MODE
+func (c *Configurator) ReadConfigFilesFromEnv(base string) error {
+	mode, _ := ModeFromEnv()
+	return c.ReadConfigFiles(base, mode)
+}
+
+func (c *Configurator) MustReadConfigFiles(base string, mode Mode) {
+	err := c.ReadConfigFiles(base, mode)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (c *Configurator) MustReadConfigFilesFromEnv(base string) {
+	err := c.ReadConfigFilesFromEnv(base)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Replaces the config values held by viper with the layered contents of c.configFiles, followed by c.providers
+func (c *Configurator) loadConfigLayers() error {
+
+	// Reading an empty document resets any previously loaded values
+	c.Viper.SetConfigType("yaml")
+	err := c.Viper.ReadConfig(strings.NewReader(""))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range c.configFiles {
+		f, err := os.Open(path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return errors.Wrapf(err, "Failed to open config file %s", path)
+		}
+
+		c.Viper.SetConfigType(strings.TrimPrefix(filepath.Ext(path), "."))
+		err = c.Viper.MergeConfig(f)
+		f.Close()
+
+		if err != nil {
+			return errors.Wrapf(err, "Failed to read config file %s", path)
+		}
+	}
+
+	return c.loadProviders()
+}
+
+// Returns the overlay path for a mode, e.g. config.PROD.yaml for config.yaml
+func configOverlayPath(base string, mode Mode) string {
+	ext := filepath.Ext(base)
+	return strings.TrimSuffix(base, ext) + "." + mode.String() + ext
+}
@@ -0,0 +1,151 @@
+package october
+
+import (
+	"encoding/csv"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+)
+
+// A pflag.Value holding the raw flag string, decoded with the rest of the config by DecodeEnv.
+// Slice flags follow pflag.StringSlice, values are comma separated and repeated flags append.
+type configFlagValue struct {
+	value    string
+	values   []string // Values of slice flags
+	slice    bool
+	changed  bool
+	typeName string
+}
+
+func (v *configFlagValue) String() string {
+	return v.value
+}
+
+func (v *configFlagValue) Set(value string) error {
+	v.value = value
+	if !v.slice {
+		return nil
+	}
+
+	values, err := csv.NewReader(strings.NewReader(value)).Read()
+	if err != nil {
+		return err
+	}
+
+	// The first value replaces the default
+	if !v.changed {
+		v.values = nil
+	}
+	v.values = append(v.values, values...)
+	v.value = strings.Join(v.values, ",")
+	v.changed = true
+
+	return nil
+}
+
+func (v *configFlagValue) Type() string {
+	return v.typeName
+}
+
+// Define a flag on fs for every october tagged field of val, and bind it to the field's key.
+// Flag names are keys with dots and underscores replaced by dashes, e.g. --db-host for db.host.
+// Help text comes from description tags, defaults from default tags, and prefix is used to name the matching environment variable.
+// Values are taken in order of precedence: flags, environment variables, config files, defaults.
+// fs must be parsed before calling DecodeEnv.
+func (c *Configurator) BindFlags(fs *pflag.FlagSet, val interface{}, prefix string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prefix = strings.TrimSpace(prefix)
+
+	for _, field := range getTaggedConfigFields(val) {
+		name := configFlagName(field.Key)
+		if fs.Lookup(name) != nil {
+			return errors.Errorf("Flag --%s for config key %s is already defined", name, field.Key)
+		}
+
+		usage := strings.TrimSpace(field.Field.Tag.Get(configDescriptionTagName))
+		usage = strings.TrimSpace(usage + " (env " + configEnvName(prefix, field.Key) + ")")
+
+		value := &configFlagValue{
+			value:    field.Field.Tag.Get(configDefaultTagName),
+			typeName: configFlagType(field.Field.Type),
+		}
+		value.slice = value.typeName == "strings"
+
+		flag := fs.VarPF(value, name, "", usage)
+		if value.typeName == "bool" {
+			flag.NoOptDefVal = "true"
+		}
+
+		if c.flags == nil {
+			c.flags = make(map[string]*pflag.Flag)
+		}
+		c.flags[field.Key] = flag
+	}
+
+	return nil
+}
+
+func (c *Configurator) MustBindFlags(fs *pflag.FlagSet, val interface{}, prefix string) {
+	err := c.BindFlags(fs, val, prefix)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Returns true if key was set by a command line flag
+func (c *Configurator) flagChanged(key string) bool {
+	flag, ok := c.flags[key]
+	return ok && flag.Changed
+}
+
+// Set key in overrides to its flag, if the flag was set on the command line.
+// Flags left unset are ignored, their defaults already come from default tags.
+func (c *Configurator) applyFlag(overrides map[string]interface{}, key string) {
+	if !c.flagChanged(key) {
+		return
+	}
+
+	value := c.flags[key].Value
+	if flagValue, ok := value.(*configFlagValue); ok && flagValue.slice {
+		overrides[key] = append([]string(nil), flagValue.values...)
+		return
+	}
+
+	overrides[key] = value.String()
+}
+
+// Returns the flag name for a config key, e.g. db-host for db.host
+func configFlagName(key string) string {
+	return strings.NewReplacer(".", "-", "_", "-").Replace(strings.ToLower(key))
+}
+
+// Returns the type name shown in flag help
+func configFlagType(t reflect.Type) string {
+	if t == durationType {
+		return "duration"
+	}
+
+	// Named types such as Mode are decoded from their names
+	if t.PkgPath() != "" && t.Kind() != reflect.Struct {
+		return "string"
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "int"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "uint"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	case reflect.Slice:
+		return "strings"
+	}
+
+	return "string"
+}
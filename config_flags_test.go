@@ -0,0 +1,93 @@
+package october
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+type testFlagConfig struct {
+	Hosts []string `october:"hosts" default:"localhost"`
+	Port  int      `october:"port" default:"8080"`
+	Debug bool     `october:"debug"`
+}
+
+func TestBindFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want testFlagConfig
+	}{
+		{"defaults", nil, testFlagConfig{Hosts: []string{"localhost"}, Port: 8080}},
+		{"single values", []string{"--port", "9090", "--debug"}, testFlagConfig{Hosts: []string{"localhost"}, Port: 9090, Debug: true}},
+		{"repeated slice", []string{"--hosts", "a", "--hosts", "b"}, testFlagConfig{Hosts: []string{"a", "b"}, Port: 8080}},
+		{"comma separated slice", []string{"--hosts", "a,b", "--hosts", "c"}, testFlagConfig{Hosts: []string{"a", "b", "c"}, Port: 8080}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := NewEnvConfigurator()
+			fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+
+			err := c.BindFlags(fs, &testFlagConfig{}, "TESTFLAG")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = fs.Parse(test.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var config testFlagConfig
+			err = c.DecodeEnv(&config, "TESTFLAG")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(config, test.want) {
+				t.Errorf("got %+v, want %+v", config, test.want)
+			}
+		})
+	}
+}
+
+func TestFlagPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "config.yaml")
+	writeTestFile(t, base, "port: 7070\n")
+
+	c := NewEnvConfigurator()
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+
+	err := c.BindFlags(fs, &testFlagConfig{}, "TESTFLAG")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.ReadConfigFiles(base, LOCAL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("TESTFLAG_PORT", "6060")
+	err = fs.Parse([]string{"--port", "9090"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var config testFlagConfig
+	err = c.DecodeEnv(&config, "TESTFLAG")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Port != 9090 {
+		t.Errorf("got port %d, want the flag's 9090 over env and config files", config.Port)
+	}
+
+	// Flags aren't written into viper, so other sources are still visible to it
+	if port := c.Viper.GetInt("port"); port != 6060 {
+		t.Errorf("viper has port %d, want the env's 6060", port)
+	}
+}
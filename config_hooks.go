@@ -0,0 +1,139 @@
+package october
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+var (
+	registeredDecodeHooksLock sync.Mutex
+	registeredDecodeHooks     []mapstructure.DecodeHookFunc
+)
+
+// Register hooks used by every Configurator, run before October's built in hooks
+func RegisterDecodeHook(hooks ...mapstructure.DecodeHookFunc) {
+	registeredDecodeHooksLock.Lock()
+	defer registeredDecodeHooksLock.Unlock()
+
+	registeredDecodeHooks = append(registeredDecodeHooks, hooks...)
+}
+
+// Add hooks used by this Configurator only, run before hooks from RegisterDecodeHook
+func (c *Configurator) WithDecodeHooks(hooks ...mapstructure.DecodeHookFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.decodeHooks = append(c.decodeHooks, hooks...)
+}
+
+// Returns every hook in the order they run: extra, registered, then built in.
+// Built in hooks decode strings into:
+// - time.Duration, e.g. 5s
+// - net.IP and net.IPNet, e.g. 10.0.0.1 and 10.0.0.0/8
+// - url.URL, e.g. https://example.com/path
+// - time.Location, e.g. America/New_York
+// - regexp.Regexp
+// - tls.Config, from "crt_path,key_path"
+// - map[string]string, from "k=v,k2=v2"
+// - Anything implementing encoding.TextUnmarshaler, including Mode (e.g. PROD), ByteSize (e.g. 64MiB) and zapcore.Level
+// - Slices, split on commas
+// Pointers to any of these types are also supported.
+func configDecodeHooks(extra []mapstructure.DecodeHookFunc) []mapstructure.DecodeHookFunc {
+	registeredDecodeHooksLock.Lock()
+	defer registeredDecodeHooksLock.Unlock()
+
+	var hooks []mapstructure.DecodeHookFunc
+	hooks = append(hooks, extra...)
+	hooks = append(hooks, registeredDecodeHooks...)
+	hooks = append(hooks,
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToIPHookFunc(),
+		mapstructure.StringToIPNetHookFunc(),
+		stringToURLHookFunc(),
+		stringToLocationHookFunc(),
+		stringToRegexpHookFunc(),
+		stringToTLSConfigHookFunc(),
+		stringToStringMapHookFunc(),
+		mapstructure.TextUnmarshallerHookFunc(),
+
+		// Last, as slices such as net.IP must be handled above first
+		mapstructure.StringToSliceHookFunc(","),
+	)
+
+	return hooks
+}
+
+// Returns a hook decoding strings into values of type target, using parse
+func stringToTypeHookFunc(target reflect.Type, parse func(string) (interface{}, error)) mapstructure.DecodeHookFuncType {
+	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if f.Kind() != reflect.String || t != target {
+			return data, nil
+		}
+
+		return parse(reflect.ValueOf(data).String())
+	}
+}
+
+func stringToURLHookFunc() mapstructure.DecodeHookFuncType {
+	return stringToTypeHookFunc(reflect.TypeOf(url.URL{}), func(s string) (interface{}, error) {
+		return url.Parse(strings.TrimSpace(s))
+	})
+}
+
+func stringToLocationHookFunc() mapstructure.DecodeHookFuncType {
+	return stringToTypeHookFunc(reflect.TypeOf(time.Location{}), func(s string) (interface{}, error) {
+		return time.LoadLocation(strings.TrimSpace(s))
+	})
+}
+
+func stringToRegexpHookFunc() mapstructure.DecodeHookFuncType {
+	return stringToTypeHookFunc(reflect.TypeOf(regexp.Regexp{}), func(s string) (interface{}, error) {
+		return regexp.Compile(s)
+	})
+}
+
+// Loads a certificate and key from "crt_path,key_path"
+func stringToTLSConfigHookFunc() mapstructure.DecodeHookFuncType {
+	return stringToTypeHookFunc(reflect.TypeOf(tls.Config{}), func(s string) (interface{}, error) {
+		paths := strings.Split(s, ",")
+		if len(paths) != 2 {
+			return nil, fmt.Errorf("expected TLS config as \"crt_path,key_path\", got %q", s)
+		}
+
+		cert, err := tls.LoadX509KeyPair(strings.TrimSpace(paths[0]), strings.TrimSpace(paths[1]))
+		if err != nil {
+			return nil, err
+		}
+
+		return &tls.Config{Certificates: []tls.Certificate{cert}}, nil
+	})
+}
+
+// Decodes "k=v,k2=v2" into a map[string]string
+func stringToStringMapHookFunc() mapstructure.DecodeHookFuncType {
+	return stringToTypeHookFunc(reflect.TypeOf(map[string]string{}), func(s string) (interface{}, error) {
+		m := make(map[string]string)
+		if strings.TrimSpace(s) == "" {
+			return m, nil
+		}
+
+		for _, pair := range strings.Split(s, ",") {
+			eq := strings.Index(pair, "=")
+			if eq < 0 {
+				return nil, fmt.Errorf("expected k=v pairs, got %q", pair)
+			}
+
+			m[strings.TrimSpace(pair[:eq])] = strings.TrimSpace(pair[eq+1:])
+		}
+
+		return m, nil
+	})
+}
@@ -0,0 +1,275 @@
+package october
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"go.uber.org/zap/zapcore"
+)
+
+type testHooksConfig struct {
+	Timeout  time.Duration     `october:"timeout"`
+	IP       net.IP            `october:"ip"`
+	Network  net.IPNet         `october:"network"`
+	URL      url.URL           `october:"url"`
+	Location time.Location     `october:"location"`
+	Pattern  regexp.Regexp     `october:"pattern"`
+	TLS      tls.Config        `october:"tls"`
+	Labels   map[string]string `october:"labels"`
+	Mode     Mode              `october:"mode"`
+	Size     ByteSize          `october:"size"`
+	Level    zapcore.Level     `october:"level"`
+	Hosts    []string          `october:"hosts"`
+}
+
+type testHooksPointerConfig struct {
+	Timeout  *time.Duration     `october:"timeout"`
+	IP       *net.IP            `october:"ip"`
+	Network  *net.IPNet         `october:"network"`
+	URL      *url.URL           `october:"url"`
+	Location *time.Location     `october:"location"`
+	Pattern  *regexp.Regexp     `october:"pattern"`
+	TLS      *tls.Config        `october:"tls"`
+	Labels   *map[string]string `october:"labels"`
+	Mode     *Mode              `october:"mode"`
+	Size     *ByteSize          `october:"size"`
+	Level    *zapcore.Level     `october:"level"`
+	Hosts    *[]string          `october:"hosts"`
+}
+
+// Writes a self signed certificate and key, returning "crt_path,key_path"
+func writeTestKeyPair(t *testing.T) string {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "october.test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	crtPath, keyPath := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeTestFile(t, crtPath, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert})))
+	writeTestFile(t, keyPath, string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})))
+
+	return crtPath + "," + keyPath
+}
+
+func setTestHooksEnv(t *testing.T) {
+	t.Setenv("TESTHOOK_TIMEOUT", "5s")
+	t.Setenv("TESTHOOK_IP", "10.0.0.1")
+	t.Setenv("TESTHOOK_NETWORK", "10.0.0.0/8")
+	t.Setenv("TESTHOOK_URL", "https://example.com/path")
+	t.Setenv("TESTHOOK_LOCATION", "UTC")
+	t.Setenv("TESTHOOK_PATTERN", "^a+$")
+	t.Setenv("TESTHOOK_TLS", writeTestKeyPair(t))
+	t.Setenv("TESTHOOK_LABELS", "team=core, tier = web")
+	t.Setenv("TESTHOOK_MODE", "DEV")
+	t.Setenv("TESTHOOK_SIZE", "64MiB")
+	t.Setenv("TESTHOOK_LEVEL", "warn")
+	t.Setenv("TESTHOOK_HOSTS", "a,b")
+}
+
+func TestDecodeEnvHooks(t *testing.T) {
+	setTestHooksEnv(t)
+
+	var config testHooksConfig
+	err := NewEnvConfigurator().DecodeEnv(&config, "TESTHOOK")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Timeout != 5*time.Second {
+		t.Errorf("got timeout %s", config.Timeout)
+	}
+	if !config.IP.Equal(net.ParseIP("10.0.0.1")) {
+		t.Errorf("got ip %s", config.IP)
+	}
+	if config.Network.String() != "10.0.0.0/8" {
+		t.Errorf("got network %s", config.Network.String())
+	}
+	if config.URL.String() != "https://example.com/path" {
+		t.Errorf("got url %s", config.URL.String())
+	}
+	if config.Location.String() != "UTC" {
+		t.Errorf("got location %s", config.Location.String())
+	}
+	if !config.Pattern.MatchString("aaa") || config.Pattern.MatchString("b") {
+		t.Errorf("got pattern %s", config.Pattern.String())
+	}
+	if len(config.TLS.Certificates) != 1 {
+		t.Errorf("got %d certificates, want 1", len(config.TLS.Certificates))
+	}
+	if !reflect.DeepEqual(config.Labels, map[string]string{"team": "core", "tier": "web"}) {
+		t.Errorf("got labels %v", config.Labels)
+	}
+	if config.Mode != DEV {
+		t.Errorf("got mode %s", config.Mode)
+	}
+	if config.Size != 64*MiB {
+		t.Errorf("got size %s", config.Size)
+	}
+	if config.Level != zapcore.WarnLevel {
+		t.Errorf("got level %s", config.Level)
+	}
+	if !reflect.DeepEqual(config.Hosts, []string{"a", "b"}) {
+		t.Errorf("got hosts %v", config.Hosts)
+	}
+}
+
+func TestDecodeEnvHooksPointers(t *testing.T) {
+	setTestHooksEnv(t)
+
+	var config testHooksPointerConfig
+	err := NewEnvConfigurator().DecodeEnv(&config, "TESTHOOK")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Timeout == nil || *config.Timeout != 5*time.Second {
+		t.Errorf("got timeout %v", config.Timeout)
+	}
+	if config.IP == nil || !config.IP.Equal(net.ParseIP("10.0.0.1")) {
+		t.Errorf("got ip %v", config.IP)
+	}
+	if config.Network == nil || config.Network.String() != "10.0.0.0/8" {
+		t.Errorf("got network %v", config.Network)
+	}
+	if config.URL == nil || config.URL.String() != "https://example.com/path" {
+		t.Errorf("got url %v", config.URL)
+	}
+	if config.Location == nil || config.Location.String() != "UTC" {
+		t.Errorf("got location %v", config.Location)
+	}
+	if config.Pattern == nil || !config.Pattern.MatchString("aaa") {
+		t.Errorf("got pattern %v", config.Pattern)
+	}
+	if config.TLS == nil || len(config.TLS.Certificates) != 1 {
+		t.Errorf("got tls %v", config.TLS)
+	}
+	if config.Labels == nil || !reflect.DeepEqual(*config.Labels, map[string]string{"team": "core", "tier": "web"}) {
+		t.Errorf("got labels %v", config.Labels)
+	}
+	if config.Mode == nil || *config.Mode != DEV {
+		t.Errorf("got mode %v", config.Mode)
+	}
+	if config.Size == nil || *config.Size != 64*MiB {
+		t.Errorf("got size %v", config.Size)
+	}
+	if config.Level == nil || *config.Level != zapcore.WarnLevel {
+		t.Errorf("got level %v", config.Level)
+	}
+	if config.Hosts == nil || !reflect.DeepEqual(*config.Hosts, []string{"a", "b"}) {
+		t.Errorf("got hosts %v", config.Hosts)
+	}
+}
+
+func TestDecodeEnvHookErrors(t *testing.T) {
+	tests := []struct {
+		env   string
+		value string
+	}{
+		{"TESTHOOK_TIMEOUT", "soon"},
+		{"TESTHOOK_LOCATION", "Nowhere/City"},
+		{"TESTHOOK_PATTERN", "("},
+		{"TESTHOOK_TLS", "only_one_path"},
+		{"TESTHOOK_LABELS", "team"},
+		{"TESTHOOK_SIZE", "-1MB"},
+		{"TESTHOOK_LEVEL", "loud"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.env, func(t *testing.T) {
+			t.Setenv(test.env, test.value)
+
+			var config testHooksConfig
+			err := NewEnvConfigurator().DecodeEnv(&config, "TESTHOOK")
+			if err == nil {
+				t.Errorf("%s=%s decoded without error", test.env, test.value)
+			}
+		})
+	}
+}
+
+type testHookString string
+
+// Appends suffix to strings decoded into a testHookString
+func appendTestHookFunc(suffix string) mapstructure.DecodeHookFuncType {
+	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if f.Kind() != reflect.String || t != reflect.TypeOf(testHookString("")) {
+			return data, nil
+		}
+
+		return reflect.ValueOf(data).String() + suffix, nil
+	}
+}
+
+func TestDecodeHookOrder(t *testing.T) {
+	registeredDecodeHooksLock.Lock()
+	registered := registeredDecodeHooks
+	registeredDecodeHooksLock.Unlock()
+	defer func() {
+		registeredDecodeHooksLock.Lock()
+		registeredDecodeHooks = registered
+		registeredDecodeHooksLock.Unlock()
+	}()
+
+	RegisterDecodeHook(appendTestHookFunc("+global"))
+
+	t.Setenv("TESTHOOK_VALUE", "env")
+
+	var config struct {
+		Value testHookString `october:"value"`
+	}
+
+	c := NewEnvConfigurator()
+	c.WithDecodeHooks(appendTestHookFunc("+configurator1"), appendTestHookFunc("+configurator2"))
+
+	err := c.DecodeEnv(&config, "TESTHOOK")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := testHookString("env+configurator1+configurator2+global"); config.Value != want {
+		t.Errorf("got %q, want %q", config.Value, want)
+	}
+
+	// Global hooks apply to other Configurators, per Configurator hooks do not
+	err = NewEnvConfigurator().DecodeEnv(&config, "TESTHOOK")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := testHookString("env+global"); config.Value != want {
+		t.Errorf("got %q from another Configurator, want %q", config.Value, want)
+	}
+}
@@ -0,0 +1,111 @@
+package october
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// A source of config values, such as a key/value store.
+// Providers are layered above config files and below environment variables and flags,
+// with providers added later taking precedence over those added earlier.
+type ConfigProvider interface {
+	Name() string
+
+	// Returns every value held by the provider, keyed by config key.
+	// Keys may be dotted (db.host) or nested maps ({"db": {"host": ...}}).
+	Load() (map[string]interface{}, error)
+}
+
+// A ConfigProvider able to report changes, used by ConfigWatcher to reload
+type WatchableConfigProvider interface {
+	ConfigProvider
+
+	// Start watching for changes, calling onChange whenever values may have changed, until stop is closed.
+	// Must not block.
+	Watch(onChange func(), stop <-chan struct{}) error
+}
+
+// Add providers and load their values.
+// Providers are loaded again by ReadConfigFiles and every ConfigWatcher reload.
+func (c *Configurator) WithProviders(providers ...ConfigProvider) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.providers = append(c.providers, providers...)
+
+	return c.loadConfigLayers()
+}
+
+func (c *Configurator) MustWithProviders(providers ...ConfigProvider) {
+	err := c.WithProviders(providers...)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Merges every provider's values into viper, in order
+func (c *Configurator) loadProviders() error {
+	for _, provider := range c.providers {
+		values, err := provider.Load()
+		if err != nil {
+			return errors.Wrapf(err, "Failed to load config provider %s", provider.Name())
+		}
+
+		err = c.Viper.MergeConfigMap(expandConfigKeys(values))
+		if err != nil {
+			return errors.Wrapf(err, "Failed to merge config provider %s", provider.Name())
+		}
+	}
+
+	return nil
+}
+
+// Returns values with dotted keys expanded into nested maps, e.g. {"db.host": x} becomes {"db": {"host": x}}
+func expandConfigKeys(values map[string]interface{}) map[string]interface{} {
+	expanded := make(map[string]interface{})
+
+	for key, value := range values {
+		if nested, ok := value.(map[string]interface{}); ok {
+			value = expandConfigKeys(nested)
+		}
+
+		path := strings.Split(strings.ToLower(key), ".")
+
+		m := expanded
+		for _, segment := range path[:len(path)-1] {
+			next, ok := m[segment].(map[string]interface{})
+			if !ok {
+				next = make(map[string]interface{})
+				m[segment] = next
+			}
+			m = next
+		}
+
+		last := path[len(path)-1]
+		if existing, ok := m[last].(map[string]interface{}); ok {
+			if nested, ok := value.(map[string]interface{}); ok {
+				mergeConfigMaps(existing, nested)
+				continue
+			}
+		}
+
+		m[last] = value
+	}
+
+	return expanded
+}
+
+// Merges src into dst, recursing into nested maps
+func mergeConfigMaps(dst, src map[string]interface{}) {
+	for key, value := range src {
+		if srcMap, ok := value.(map[string]interface{}); ok {
+			if dstMap, ok := dst[key].(map[string]interface{}); ok {
+				mergeConfigMaps(dstMap, srcMap)
+				continue
+			}
+		}
+
+		dst[key] = value
+	}
+}
@@ -0,0 +1,190 @@
+package october
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+// A ConfigProvider reading a directory where every file is a key and its contents the value,
+// similar to a Consul or etcd key/value tree, or a mounted kubernetes ConfigMap.
+// Nested directories nest keys, e.g. db/host holds the value of db.host. Hidden files are ignored.
+type DirectoryConfigProvider struct {
+	Dir string
+}
+
+func NewDirectoryConfigProvider(dir string) *DirectoryConfigProvider {
+	return &DirectoryConfigProvider{Dir: dir}
+}
+
+func (d *DirectoryConfigProvider) Name() string {
+	return "directory:" + d.Dir
+}
+
+func (d *DirectoryConfigProvider) Load() (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+
+	err := filepath.Walk(d.Dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if path != d.Dir && strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(d.Dir, path)
+		if err != nil {
+			return err
+		}
+
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		key := strings.ReplaceAll(filepath.ToSlash(rel), "/", ".")
+		values[key] = strings.TrimSpace(string(contents))
+
+		return nil
+	})
+
+	return values, err
+}
+
+// Watches the directory and every directory below it, including directories created after Watch is called
+func (d *DirectoryConfigProvider) Watch(onChange func(), stop <-chan struct{}) error {
+	dirs, err := walkConfigDirs(d.Dir)
+	if err != nil {
+		return err
+	}
+
+	return watchConfigPaths(d.Name(), dirs, true, nil, onChange, stop)
+}
+
+// Returns root and every directory below it
+func walkConfigDirs(root string) ([]string, error) {
+	var dirs []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.IsDir() {
+			dirs = append(dirs, path)
+		}
+		return err
+	})
+
+	return dirs, err
+}
+
+// A ConfigProvider reading a JSON object from a file, with dotted or nested keys
+type JSONFileConfigProvider struct {
+	Path string
+}
+
+func NewJSONFileConfigProvider(path string) *JSONFileConfigProvider {
+	return &JSONFileConfigProvider{Path: path}
+}
+
+func (j *JSONFileConfigProvider) Name() string {
+	return "json:" + j.Path
+}
+
+func (j *JSONFileConfigProvider) Load() (map[string]interface{}, error) {
+	contents, err := ioutil.ReadFile(j.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{})
+	err = json.Unmarshal(contents, &values)
+
+	return values, err
+}
+
+func (j *JSONFileConfigProvider) Watch(onChange func(), stop <-chan struct{}) error {
+	path := filepath.Clean(j.Path)
+
+	// Only the file matters, not everything else in its directory
+	match := func(event fsnotify.Event) bool {
+		return filepath.Clean(event.Name) == path && event.Op != fsnotify.Chmod
+	}
+
+	return watchConfigPaths(j.Name(), []string{filepath.Dir(path)}, false, match, onChange, stop)
+}
+
+// Watch paths with fsnotify, calling onChange for every event matched by match, or every event if it's nil, until stop is closed.
+// With recursive set, directories created below paths are watched as well.
+func watchConfigPaths(name string, paths []string, recursive bool, match func(fsnotify.Event) bool, onChange func(), stop <-chan struct{}) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		err = watcher.Add(path)
+		if err != nil {
+			watcher.Close()
+			return err
+		}
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		for {
+			select {
+			case <-stop:
+				return
+
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if recursive && event.Op&fsnotify.Create != 0 {
+					watchCreatedConfigDir(name, watcher, event.Name)
+				}
+				if match == nil || match(event) {
+					onChange()
+				}
+
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				zap.L().Named("OCTOBER").Warn("Config provider watcher error", zap.String("provider", name), zap.Error(err))
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Adds path and every directory below it to watcher, if path is a directory.
+// Each directory is watched before it's read, so directories created meanwhile are not missed.
+func watchCreatedConfigDir(name string, watcher *fsnotify.Watcher, path string) {
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		return
+	}
+
+	err = filepath.Walk(path, func(dir string, info os.FileInfo, err error) error {
+		if err == nil && info.IsDir() {
+			err = watcher.Add(dir)
+		}
+		return err
+	})
+	if err != nil {
+		zap.L().Named("OCTOBER").Warn("Failed to watch new config provider directory", zap.String("provider", name), zap.String("dir", path), zap.Error(err))
+	}
+}
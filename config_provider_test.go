@@ -0,0 +1,299 @@
+package october
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testMapConfigProvider struct {
+	name   string
+	values map[string]interface{}
+}
+
+func (m *testMapConfigProvider) Name() string {
+	return m.name
+}
+
+func (m *testMapConfigProvider) Load() (map[string]interface{}, error) {
+	return m.values, nil
+}
+
+func TestDirectoryConfigProviderLoad(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{"db", ".hidden"} {
+		err := os.Mkdir(filepath.Join(dir, sub), 0700)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeTestFile(t, filepath.Join(dir, "name"), "app\n")
+	writeTestFile(t, filepath.Join(dir, "db", "host"), " localhost ")
+	writeTestFile(t, filepath.Join(dir, ".ignored"), "x")
+	writeTestFile(t, filepath.Join(dir, ".hidden", "key"), "x")
+
+	values, err := NewDirectoryConfigProvider(dir).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]interface{}{"name": "app", "db.host": "localhost"}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("got %v, want %v", values, want)
+	}
+
+	_, err = NewDirectoryConfigProvider(filepath.Join(dir, "missing")).Load()
+	if err == nil {
+		t.Error("missing directory loaded without error")
+	}
+}
+
+func TestJSONFileConfigProviderLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	writeTestFile(t, path, `{"name": "app", "db.port": 5432, "db": {"host": "localhost"}}`)
+
+	values, err := NewJSONFileConfigProvider(path).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]interface{}{
+		"name":    "app",
+		"db.port": float64(5432),
+		"db":      map[string]interface{}{"host": "localhost"},
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("got %v, want %v", values, want)
+	}
+
+	for name, contents := range map[string]string{"invalid.json": "{", "array.json": "[1]"} {
+		writeTestFile(t, filepath.Join(dir, name), contents)
+		_, err = NewJSONFileConfigProvider(filepath.Join(dir, name)).Load()
+		if err == nil {
+			t.Errorf("%s loaded without error", name)
+		}
+	}
+
+	_, err = NewJSONFileConfigProvider(filepath.Join(dir, "missing.json")).Load()
+	if err == nil {
+		t.Error("missing file loaded without error")
+	}
+}
+
+func TestExpandConfigKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		values map[string]interface{}
+		want   map[string]interface{}
+	}{
+		{
+			"flat",
+			map[string]interface{}{"Name": "app"},
+			map[string]interface{}{"name": "app"},
+		},
+		{
+			"dotted",
+			map[string]interface{}{"db.host": "localhost", "db.pool.size": 5},
+			map[string]interface{}{"db": map[string]interface{}{"host": "localhost", "pool": map[string]interface{}{"size": 5}}},
+		},
+		{
+			"dotted and nested",
+			map[string]interface{}{"db.host": "localhost", "db": map[string]interface{}{"PORT": 5432}},
+			map[string]interface{}{"db": map[string]interface{}{"host": "localhost", "port": 5432}},
+		},
+		{
+			"nested with dotted keys",
+			map[string]interface{}{"db": map[string]interface{}{"pool.size": 5}},
+			map[string]interface{}{"db": map[string]interface{}{"pool": map[string]interface{}{"size": 5}}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := expandConfigKeys(test.values); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMergeConfigMaps(t *testing.T) {
+	dst := map[string]interface{}{
+		"name": "old",
+		"db":   map[string]interface{}{"host": "localhost", "port": 5432},
+		"tags": map[string]interface{}{"a": "1"},
+	}
+	src := map[string]interface{}{
+		"name": "new",
+		"db":   map[string]interface{}{"port": 6432, "user": "app"},
+		"tags": "replaced",
+		"log":  map[string]interface{}{"level": "debug"},
+	}
+
+	mergeConfigMaps(dst, src)
+
+	want := map[string]interface{}{
+		"name": "new",
+		"db":   map[string]interface{}{"host": "localhost", "port": 6432, "user": "app"},
+		"tags": "replaced",
+		"log":  map[string]interface{}{"level": "debug"},
+	}
+	if !reflect.DeepEqual(dst, want) {
+		t.Errorf("got %v, want %v", dst, want)
+	}
+}
+
+type testProviderConfig struct {
+	File     string `october:"file"`
+	Provider string `october:"provider"`
+	Later    string `october:"later"`
+	Env      string `october:"env"`
+	DB       struct {
+		Host string `october:"host"`
+	} `october:"db"`
+}
+
+func TestConfigProviderPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	writeTestFile(t, path, "file: file\nprovider: file\nlater: file\nenv: file\ndb:\n  host: file\n")
+
+	c := NewEnvConfigurator()
+	err := c.ReadConfigFiles(path, LOCAL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.WithProviders(
+		&testMapConfigProvider{"first", map[string]interface{}{"provider": "first", "later": "first", "env": "first", "db.host": "first"}},
+		&testMapConfigProvider{"second", map[string]interface{}{"later": "second"}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("TESTPROVIDER_ENV", "env")
+
+	var config testProviderConfig
+	err = c.DecodeEnv(&config, "TESTPROVIDER")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.File != "file" || config.Provider != "first" || config.Later != "second" || config.Env != "env" || config.DB.Host != "first" {
+		t.Errorf("got %+v, want files < providers in order < env", config)
+	}
+
+	// Reading the files again keeps the providers layered above them
+	err = c.ReadConfigFiles(path, LOCAL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.DecodeEnv(&config, "TESTPROVIDER")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Provider != "first" || config.Later != "second" {
+		t.Errorf("got %+v after reading config files again", config)
+	}
+}
+
+func TestConfigLayersReplaceViperConfig(t *testing.T) {
+	c := NewEnvConfigurator()
+	c.Viper.Set("env", "set")
+
+	err := c.Viper.MergeConfigMap(map[string]interface{}{"file": "merged"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.WithProviders(&testMapConfigProvider{"provider", map[string]interface{}{"provider": "provider"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var config testProviderConfig
+	err = c.DecodeEnv(&config, "TESTPROVIDER")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.File != "" || config.Provider != "provider" || config.Env != "set" {
+		t.Errorf("got %+v, want merged config replaced and Set values kept", config)
+	}
+}
+
+func expectProviderChange(t *testing.T, changes chan struct{}) {
+	t.Helper()
+
+	select {
+	case <-changes:
+	case <-time.After(2 * time.Second):
+		t.Fatal("no change reported")
+	}
+
+	// Drain events of the same change
+	for {
+		select {
+		case <-changes:
+		case <-time.After(100 * time.Millisecond):
+			return
+		}
+	}
+}
+
+func TestDirectoryConfigProviderWatchNewDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "name"), "app")
+
+	changes := make(chan struct{}, 100)
+	stop := make(chan struct{})
+	defer close(stop)
+
+	err := NewDirectoryConfigProvider(dir).Watch(func() { changes <- struct{}{} }, stop)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.MkdirAll(filepath.Join(dir, "db", "pool"), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectProviderChange(t, changes)
+
+	writeTestFile(t, filepath.Join(dir, "db", "host"), "localhost")
+	expectProviderChange(t, changes)
+
+	writeTestFile(t, filepath.Join(dir, "db", "pool", "size"), "5")
+	expectProviderChange(t, changes)
+}
+
+func TestJSONFileConfigProviderWatch(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	writeTestFile(t, path, `{"name": "first"}`)
+
+	changes := make(chan struct{}, 100)
+	stop := make(chan struct{})
+	defer close(stop)
+
+	err := NewJSONFileConfigProvider(path).Watch(func() { changes <- struct{}{} }, stop)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeTestFile(t, filepath.Join(dir, "other.json"), "{}")
+	select {
+	case <-changes:
+		t.Error("change reported for another file")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	writeTestFile(t, path, `{"name": "second"}`)
+	expectProviderChange(t, changes)
+}
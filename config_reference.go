@@ -0,0 +1,170 @@
+package october
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+const configDescriptionTagName = "description"
+
+// Documents a single config key, see ConfigReferenceFor
+type ConfigReference struct {
+	Key         string // Dotted config key, e.g. db.host
+	Env         string // Environment variable, e.g. PREFIX_DB_HOST
+	Type        string
+	Default     string // RedactedConfigValue for secrets with a default
+	Required    bool
+	Secret      bool
+	Description string // From the field's description tag
+}
+
+// Returns a reference entry for every october tagged field of val, with environment variables named using prefix
+func ConfigReferenceFor(val interface{}, prefix string) []ConfigReference {
+	var refs []ConfigReference
+	for _, field := range getTaggedConfigFields(val) {
+		ref := ConfigReference{
+			Key:         field.Key,
+			Env:         configEnvName(prefix, field.Key),
+			Type:        configTypeName(field.Field.Type),
+			Default:     field.Field.Tag.Get(configDefaultTagName),
+			Required:    hasConfigRule(field.Field, "required"),
+			Secret:      isSecretConfigField(field.Field),
+			Description: strings.TrimSpace(field.Field.Tag.Get(configDescriptionTagName)),
+		}
+
+		// References end up in docs and committed .env.example files
+		if ref.Secret && ref.Default != "" {
+			ref.Default = RedactedConfigValue
+		}
+
+		refs = append(refs, ref)
+	}
+
+	return refs
+}
+
+// Returns the reference for every OCTOBER_* variable
+func OctoberConfigReference() []ConfigReference {
+	refs := ConfigReferenceFor(&OctoberConfig{}, octoberEnvPrefix)
+
+	return append(refs, ConfigReference{
+		Env:         configKeyEnvVariable,
+		Type:        "string",
+		Secret:      true,
+		Description: "Base64 encoded keys decrypting enc: config values, comma separated",
+	})
+}
+
+// Write refs as a Markdown table
+func WriteConfigMarkdown(w io.Writer, refs []ConfigReference) error {
+	_, err := io.WriteString(w, "| Variable | Type | Default | Required | Description |\n|---|---|---|---|---|\n")
+	if err != nil {
+		return err
+	}
+
+	for _, ref := range refs {
+		def := ""
+		if ref.Default != "" {
+			def = "`" + ref.Default + "`"
+		}
+
+		required := ""
+		if ref.Required {
+			required = "yes"
+		}
+
+		description := ref.Description
+		if ref.Secret {
+			description = strings.TrimSpace(description + " (secret)")
+		}
+
+		_, err = fmt.Fprintf(w, "| `%s` | %s | %s | %s | %s |\n",
+			ref.Env,
+			escapeMarkdownCell(ref.Type),
+			escapeMarkdownCell(def),
+			required,
+			escapeMarkdownCell(description),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Write refs as a .env.example file.
+// Required variables are left uncommented and empty, optional variables are commented out with their default.
+func WriteEnvExample(w io.Writer, refs []ConfigReference) error {
+	for i, ref := range refs {
+		if i > 0 {
+			_, err := io.WriteString(w, "\n")
+			if err != nil {
+				return err
+			}
+		}
+
+		if ref.Description != "" {
+			for _, line := range strings.Split(ref.Description, "\n") {
+				_, err := fmt.Fprintf(w, "# %s\n", strings.TrimSpace(line))
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		details := []string{ref.Type}
+		if ref.Required {
+			details = append(details, "required")
+		}
+		if ref.Secret {
+			details = append(details, "secret")
+		}
+
+		line := fmt.Sprintf("# (%s)\n%s=%s\n", strings.Join(details, ", "), ref.Env, ref.Default)
+		if !ref.Required {
+			line = fmt.Sprintf("# (%s)\n# %s=%s\n", strings.Join(details, ", "), ref.Env, ref.Default)
+		}
+
+		_, err := io.WriteString(w, line)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func hasConfigRule(field reflect.StructField, name string) bool {
+	for _, rule := range strings.Split(field.Tag.Get(configValidateTagName), ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == name || strings.HasPrefix(rule, name+"=") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func configTypeName(t reflect.Type) string {
+	switch {
+	case t == durationType:
+		return "duration"
+	case t.Kind() == reflect.Ptr:
+		return configTypeName(t.Elem())
+	case t.Kind() == reflect.Slice:
+		return "list of " + configTypeName(t.Elem())
+	}
+
+	return t.String()
+}
+
+// Escapes pipes and replaces line breaks, either would end the table row
+func escapeMarkdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	s = strings.ReplaceAll(s, "\r\n", "<br>")
+
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
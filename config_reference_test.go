@@ -0,0 +1,87 @@
+package october
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testReferenceConfig struct {
+	DB struct {
+		Host string `october:"host" validate:"required" description:"Database host"`
+		Port int    `october:"port" default:"5432" description:"Port | socket"`
+	} `october:"db"`
+	Password string        `october:"password" default:"changeme" secret:"true" description:"Database password"`
+	Timeout  time.Duration `october:"timeout" default:"5s" description:"Query timeout,\nper statement"`
+	Tags     []string      `october:"tags"`
+}
+
+func TestConfigReferenceFor(t *testing.T) {
+	refs := ConfigReferenceFor(&testReferenceConfig{}, "APP")
+
+	want := []ConfigReference{
+		{Key: "db.host", Env: "APP_DB_HOST", Type: "string", Required: true, Description: "Database host"},
+		{Key: "db.port", Env: "APP_DB_PORT", Type: "int", Default: "5432", Description: "Port | socket"},
+		{Key: "password", Env: "APP_PASSWORD", Type: "string", Default: RedactedConfigValue, Secret: true, Description: "Database password"},
+		{Key: "timeout", Env: "APP_TIMEOUT", Type: "duration", Default: "5s", Description: "Query timeout,\nper statement"},
+		{Key: "tags", Env: "APP_TAGS", Type: "list of string"},
+	}
+
+	if !reflect.DeepEqual(refs, want) {
+		t.Errorf("got  %+v\nwant %+v", refs, want)
+	}
+}
+
+func TestWriteConfigMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteConfigMarkdown(&buf, ConfigReferenceFor(&testReferenceConfig{}, "APP"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "| Variable | Type | Default | Required | Description |\n" +
+		"|---|---|---|---|---|\n" +
+		"| `APP_DB_HOST` | string |  | yes | Database host |\n" +
+		"| `APP_DB_PORT` | int | `5432` |  | Port \\| socket |\n" +
+		"| `APP_PASSWORD` | string | `[REDACTED]` |  | Database password (secret) |\n" +
+		"| `APP_TIMEOUT` | duration | `5s` |  | Query timeout,<br>per statement |\n" +
+		"| `APP_TAGS` | list of string |  |  |  |\n"
+
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteEnvExample(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteEnvExample(&buf, ConfigReferenceFor(&testReferenceConfig{}, "APP"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# Database host
+# (string, required)
+APP_DB_HOST=
+
+# Port | socket
+# (int)
+# APP_DB_PORT=5432
+
+# Database password
+# (string, secret)
+# APP_PASSWORD=[REDACTED]
+
+# Query timeout,
+# per statement
+# (duration)
+# APP_TIMEOUT=5s
+
+# (list of string)
+# APP_TAGS=
+`
+
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,124 @@
+package october
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	configSecretTagName = "secret"
+	secretFileEnvSuffix = "_FILE"
+
+	// Replaces the value of secret fields in config dumps
+	RedactedConfigValue = "[REDACTED]"
+)
+
+// A config value prepared for display, see ConfigValues
+type ConfigValue struct {
+	Key    string // Dotted config key, e.g. db.password
+	Env    string // Environment variable the key is bound to, e.g. PREFIX_DB_PASSWORD
+	Value  string // Formatted value, RedactedConfigValue for secrets
+	Secret bool
+}
+
+// If env is unset and env_FILE names a file, set key in overrides to the file's contents.
+// A single trailing newline is trimmed, as most tools writing secret files add one.
+// Flags set on the command line take precedence over secret files.
+// The file is read again on every decode, so changed or removed files are picked up on reload.
+func (c *Configurator) resolveSecretFile(overrides map[string]interface{}, key, env string) error {
+	if strings.TrimSpace(os.Getenv(env)) != "" || c.flagChanged(key) {
+		return nil
+	}
+
+	path := strings.TrimSpace(os.Getenv(env + secretFileEnvSuffix))
+	if path == "" {
+		return nil
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to read %s from %s", env, env+secretFileEnvSuffix)
+	}
+
+	overrides[key] = strings.TrimSuffix(strings.TrimSuffix(string(contents), "\n"), "\r")
+
+	return nil
+}
+
+// Returns every october tagged value in val, safe to log or print.
+// Fields tagged secret:"true", and fields whose value was decrypted, have their value replaced by RedactedConfigValue, unless empty.
+func ConfigValues(val interface{}, prefix string) []ConfigValue {
+	root := reflect.ValueOf(val)
+
+	var values []ConfigValue
+	for _, field := range getTaggedConfigFields(val) {
+		env := configEnvName(prefix, field.Key)
+		cv := ConfigValue{
+			Key:    field.Key,
+			Env:    env,
+			Secret: isSecretConfigField(field.Field) || isDecryptedConfigEnv(env),
+			Value:  "(empty)",
+		}
+
+		value, ok := configFieldValue(root, field.Index)
+		if ok && !isZeroConfigValue(value) {
+			if cv.Secret {
+				cv.Value = RedactedConfigValue
+			} else {
+				cv.Value = formatConfigValue(value)
+			}
+		}
+
+		values = append(values, cv)
+	}
+
+	return values
+}
+
+// Returns zap fields for every october tagged value in val, keyed by environment variable, with secrets redacted
+func ConfigZapFields(val interface{}, prefix string) []zap.Field {
+	var fields []zap.Field
+	for _, cv := range ConfigValues(val, prefix) {
+		fields = append(fields, zap.String(cv.Env, cv.Value))
+	}
+
+	return fields
+}
+
+func isSecretConfigField(field reflect.StructField) bool {
+	switch strings.ToLower(strings.TrimSpace(field.Tag.Get(configSecretTagName))) {
+	case "true", "1", "yes":
+		return true
+	}
+
+	return false
+}
+
+func formatConfigValue(value reflect.Value) string {
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return "(empty)"
+		}
+		value = value.Elem()
+	}
+
+	if value.Kind() == reflect.Slice || value.Kind() == reflect.Array {
+		var parts []string
+		for i := 0; i < value.Len(); i++ {
+			parts = append(parts, fmt.Sprint(value.Index(i).Interface()))
+		}
+		return strings.Join(parts, ",")
+	}
+
+	if s, ok := value.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+
+	return fmt.Sprint(value.Interface())
+}
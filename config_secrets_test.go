@@ -0,0 +1,62 @@
+package october
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+type testSecretConfig struct {
+	Password string `october:"password" secret:"true"`
+	User     string `october:"user"`
+}
+
+func TestResolveSecretFile(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "password")
+	writeTestFile(t, secret, "first\n")
+
+	base := filepath.Join(dir, "config.yaml")
+	writeTestFile(t, base, "password: from-config\nuser: from-config\n")
+
+	t.Setenv("TESTSECRET_PASSWORD_FILE", secret)
+
+	c := NewEnvConfigurator()
+	err := c.ReadConfigFiles(base, LOCAL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decode := func() testSecretConfig {
+		t.Helper()
+
+		var config testSecretConfig
+		err := c.DecodeEnv(&config, "TESTSECRET")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return config
+	}
+
+	if config := decode(); config.Password != "first" || config.User != "from-config" {
+		t.Errorf("got %+v, want password from file and user from config", config)
+	}
+
+	// Read again on every decode
+	writeTestFile(t, secret, "second\n")
+	if config := decode(); config.Password != "second" {
+		t.Errorf("got password %q after the file changed, want second", config.Password)
+	}
+
+	// Environment variables take precedence over files
+	t.Setenv("TESTSECRET_PASSWORD", "from-env")
+	if config := decode(); config.Password != "from-env" {
+		t.Errorf("got password %q with the variable set, want from-env", config.Password)
+	}
+
+	// Nothing sticks once the file is no longer named
+	t.Setenv("TESTSECRET_PASSWORD", "")
+	t.Setenv("TESTSECRET_PASSWORD_FILE", "")
+	if config := decode(); config.Password != "from-config" {
+		t.Errorf("got password %q without a file, want from-config", config.Password)
+	}
+}
@@ -0,0 +1,180 @@
+package october
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// Controls how DecodeEnv treats environment variables with its prefix that don't match any config key
+type StrictEnvMode uint8
+
+const (
+	StrictEnvOff   StrictEnvMode = iota // Ignore unknown variables
+	StrictEnvWarn                       // Log a warning for each unknown variable
+	StrictEnvError                      // Fail decoding with an *UnknownEnvError
+)
+
+// Longest edit distance between an unknown variable and a known one to suggest it
+const maxEnvSuggestionDistance = 3
+
+// An environment variable with a config prefix that doesn't match any config key
+type UnknownEnvVariable struct {
+	Name       string
+	Suggestion string // Closest known variable, empty if none are close
+}
+
+func (u UnknownEnvVariable) String() string {
+	if u.Suggestion != "" {
+		return fmt.Sprintf("%s (did you mean %s?)", u.Name, u.Suggestion)
+	}
+
+	return u.Name
+}
+
+// Returned by DecodeEnv in StrictEnvError mode when unknown environment variables are set
+type UnknownEnvError struct {
+	Unknown []UnknownEnvVariable
+}
+
+func (e *UnknownEnvError) Error() string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("Unknown environment variables (%d):", len(e.Unknown)))
+	for _, u := range e.Unknown {
+		b.WriteString("\n    ")
+		b.WriteString(u.String())
+	}
+
+	return b.String()
+}
+
+// Check the environment for variables with the decode prefix that don't match any config key, see StrictEnvMode.
+// Only applies to DecodeEnv calls with a non-empty prefix. All config for a prefix must be decoded by a single call,
+// otherwise keys belonging to other structs will be reported as unknown.
+func (c *Configurator) WithStrictEnv(mode StrictEnvMode) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.strictEnv = mode
+}
+
+// Checks for unknown variables with prefix according to mode, known holds every valid variable name
+func checkUnknownEnv(mode StrictEnvMode, prefix string, known map[string]bool) error {
+	if mode == StrictEnvOff || prefix == "" {
+		return nil
+	}
+
+	unknown := findUnknownEnv(prefix, known)
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	if mode == StrictEnvError {
+		return &UnknownEnvError{Unknown: unknown}
+	}
+
+	warnUnknownEnv(unknown)
+
+	return nil
+}
+
+func warnUnknownEnv(unknown []UnknownEnvVariable) {
+	for _, u := range unknown {
+		zap.L().Named("OCTOBER").Warn("Unknown environment variable " + u.String())
+	}
+}
+
+// Returns every environment variable name val's config keys may be set from
+func knownConfigEnv(val interface{}, prefix string) map[string]bool {
+	known := make(map[string]bool)
+	for _, key := range getTaggedConfigKeys(val) {
+		env := configEnvName(prefix, key)
+		known[env] = true
+		known[env+secretFileEnvSuffix] = true
+	}
+
+	return known
+}
+
+func findUnknownEnv(prefix string, known map[string]bool) []UnknownEnvVariable {
+	envPrefix := strings.ToUpper(prefix) + "_"
+
+	var knownNames []string
+	for name := range known {
+		knownNames = append(knownNames, name)
+	}
+	sort.Strings(knownNames)
+
+	var unknown []UnknownEnvVariable
+	for _, kv := range os.Environ() {
+		name := kv
+		if eq := strings.Index(kv, "="); eq >= 0 {
+			name = kv[:eq]
+		}
+
+		if !strings.HasPrefix(name, envPrefix) || known[name] {
+			continue
+		}
+
+		unknown = append(unknown, UnknownEnvVariable{
+			Name:       name,
+			Suggestion: closestEnvName(name, knownNames),
+		})
+	}
+
+	sort.Slice(unknown, func(i, j int) bool {
+		return unknown[i].Name < unknown[j].Name
+	})
+
+	return unknown
+}
+
+func closestEnvName(name string, candidates []string) string {
+	best := ""
+	bestDistance := maxEnvSuggestionDistance + 1
+
+	for _, candidate := range candidates {
+		d := levenshtein(name, candidate)
+		if d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+
+	return best
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			curr[j] = minInt(minInt(prev[j]+1, curr[j-1]+1), prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
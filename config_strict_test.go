@@ -0,0 +1,128 @@
+package october
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+type testStrictConfig struct {
+	Port     int    `october:"port"`
+	Password string `october:"password"`
+	DB       struct {
+		Host string `october:"host"`
+	} `october:"db"`
+}
+
+func TestDecodeEnvStrict(t *testing.T) {
+	t.Setenv("STRICTTEST_PORT", "8080")
+	t.Setenv("STRICTTEST_PASSWORD_FILE", "/dev/null")
+	t.Setenv("STRICTTEST_DB_HOST", "localhost")
+	t.Setenv("STRICTTEST_PROT", "9090")
+	t.Setenv("STRICTTEST_DB_HOSTNAME", "db")
+	t.Setenv("STRICTTEST_UNRELATED_SETTING", "x")
+	t.Setenv("OTHER_PROT", "9090")
+
+	tests := []struct {
+		name    string
+		mode    StrictEnvMode
+		prefix  string
+		unknown []UnknownEnvVariable // nil if decoding succeeds
+	}{
+		{name: "off", mode: StrictEnvOff, prefix: "STRICTTEST"},
+		{name: "warn", mode: StrictEnvWarn, prefix: "STRICTTEST"},
+		{name: "no prefix", mode: StrictEnvError},
+		{
+			name:   "error",
+			mode:   StrictEnvError,
+			prefix: "STRICTTEST",
+			unknown: []UnknownEnvVariable{
+				{Name: "STRICTTEST_DB_HOSTNAME"}, // Too far from STRICTTEST_DB_HOST to suggest it
+				{Name: "STRICTTEST_PROT", Suggestion: "STRICTTEST_PORT"},
+				{Name: "STRICTTEST_UNRELATED_SETTING"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configurator := NewEnvConfigurator()
+			configurator.WithStrictEnv(test.mode)
+
+			var config testStrictConfig
+			err := configurator.DecodeEnv(&config, test.prefix)
+
+			var unknownErr *UnknownEnvError
+			if test.unknown == nil {
+				if err != nil {
+					t.Fatalf("got error %v, want none", err)
+				}
+				return
+			}
+
+			if !errors.As(err, &unknownErr) {
+				t.Fatalf("got error %v, want an *UnknownEnvError", err)
+			}
+			if !reflect.DeepEqual(unknownErr.Unknown, test.unknown) {
+				t.Errorf("got unknown %v, want %v", unknownErr.Unknown, test.unknown)
+			}
+		})
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"PORT", "PORT", 0},
+		{"PROT", "PORT", 2},
+		{"HOST", "HOSTNAME", 4},
+		{"", "abc", 3},
+	}
+
+	for _, test := range tests {
+		if got := levenshtein(test.a, test.b); got != test.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestInitServiceFromEnvStrict(t *testing.T) {
+	restoreInitServiceGlobals(t)
+	previous := zap.L()
+	previousLevels := GlobalLogLevels()
+
+	sink := filepath.Join(t.TempDir(), "sink.log")
+	t.Setenv("OCTOBER_MODE", "PROD")
+	t.Setenv("OCTOBER_LOG_OUTPUT_PATHS", filepath.Join(t.TempDir(), "app.log"))
+	t.Setenv("OCTOBER_LOG_SINKS", "file://"+sink)
+	t.Setenv("OCTOBER_GRPC_PROT", "9090")
+
+	_, err := InitServiceFromEnv(StrictEnv(StrictEnvError))
+	var unknownErr *UnknownEnvError
+	if !errors.As(err, &unknownErr) {
+		t.Fatalf("got error %v, want an *UnknownEnvError", err)
+	}
+
+	if zap.L() != previous || GlobalLogLevels() != previousLevels {
+		t.Error("global logger replaced before the unknown variables were refused")
+	}
+	if _, err := os.Stat(sink); !os.IsNotExist(err) {
+		t.Error("log sink opened before the unknown variables were refused")
+	}
+
+	_, err = InitServiceFromEnv(StrictEnv(StrictEnvWarn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(readTestFile(t, sink), "OCTOBER_GRPC_PROT") {
+		t.Error("unknown variable not warned about with the configured logger")
+	}
+}
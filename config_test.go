@@ -0,0 +1,66 @@
+package october
+
+import (
+	"reflect"
+	"testing"
+)
+
+type testEmbeddedConfig struct {
+	Host string `october:"host"`
+	Port int    `october:"port" default:"5432"`
+}
+
+type testEmbeddedPointerConfig struct {
+	*testEmbeddedConfig
+	Name string `october:"name"`
+}
+
+type TestExportedEmbeddedConfig struct {
+	Host string `october:"host"`
+}
+
+type testNestedConfig struct {
+	*TestExportedEmbeddedConfig
+	DB struct {
+		testEmbeddedConfig
+		User string `october:"user"`
+	} `october:"db"`
+}
+
+func TestGetTaggedConfigKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		val  interface{}
+		want []string
+	}{
+		{"embedded pointer", &testEmbeddedPointerConfig{}, []string{"host", "port", "name"}},
+		{"nested embedded", testNestedConfig{}, []string{"host", "db.host", "db.port", "db.user"}},
+		{"not a struct", 1, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			keys := getTaggedConfigKeys(test.val)
+			if !reflect.DeepEqual(keys, test.want) {
+				t.Errorf("got keys %v, want %v", keys, test.want)
+			}
+		})
+	}
+}
+
+func TestDecodeEnvEmbeddedPointer(t *testing.T) {
+	t.Setenv("TEST_HOST", "localhost")
+
+	var config testNestedConfig
+	err := NewEnvConfigurator().DecodeEnv(&config, "TEST")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.TestExportedEmbeddedConfig == nil || config.Host != "localhost" {
+		t.Errorf("embedded pointer not decoded, got %+v", config.TestExportedEmbeddedConfig)
+	}
+	if config.DB.Port != 5432 {
+		t.Errorf("got db.port %d, want default 5432", config.DB.Port)
+	}
+}
@@ -0,0 +1,264 @@
+package october
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const configValidateTagName = "validate"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// A single invalid config value
+type ConfigViolation struct {
+	Key     string // Dotted config key, e.g. db.host
+	Env     string // Environment variable the key is bound to, e.g. PREFIX_DB_HOST
+	Message string
+}
+
+// Returned when one or more config values fail validation, lists every violation
+type ConfigValidationError struct {
+	Violations []ConfigViolation
+}
+
+func (e *ConfigValidationError) Error() string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("Invalid configuration (%d problems):", len(e.Violations)))
+	for _, v := range e.Violations {
+		b.WriteString(fmt.Sprintf("\n    %s (%s): %s", v.Env, v.Key, v.Message))
+	}
+
+	return b.String()
+}
+
+// Validates an october tagged struct against the rules in its validate tags, e.g. `october:"port" validate:"required,min=1,max=65535"`
+// Supported rules:
+// - required: value must not be the zero value
+// - min=N, max=N: bounds for numbers, durations (e.g. min=1s) and the length of strings, slices and maps
+// - oneof=a b c: value must be one of the space separated options
+// - oneofci=a b c: same as oneof, ignoring case
+// - url: value must be an absolute URL
+// - file: value must be the path of an existing file
+// Nil pointer fields, and fields nested under them, are not validated.
+// Returns a *ConfigValidationError listing every violation, prefix is used to name environment variables.
+func ValidateConfig(val interface{}, prefix string) error {
+	root := reflect.ValueOf(val)
+
+	var violations []ConfigViolation
+	for _, field := range getTaggedConfigFields(val) {
+		rules := strings.TrimSpace(field.Field.Tag.Get(configValidateTagName))
+		if rules == "" {
+			continue
+		}
+
+		value, ok := configFieldValue(root, field.Index)
+		if !ok {
+			continue
+		}
+
+		// Optional fields are validated when set
+		if value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				continue
+			}
+			value = value.Elem()
+		}
+
+		for _, rule := range strings.Split(rules, ",") {
+			msg := validateConfigRule(strings.TrimSpace(rule), value)
+			if msg != "" {
+				violations = append(violations, ConfigViolation{
+					Key:     field.Key,
+					Env:     configEnvName(prefix, field.Key),
+					Message: msg,
+				})
+			}
+		}
+	}
+
+	if len(violations) > 0 {
+		return &ConfigValidationError{Violations: violations}
+	}
+
+	return nil
+}
+
+// Returns the value at index within root, following pointers. Returns false if a pointer on the way is nil
+func configFieldValue(root reflect.Value, index []int) (reflect.Value, bool) {
+	value := root
+	for _, i := range index {
+		for value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				return reflect.Value{}, false
+			}
+			value = value.Elem()
+		}
+
+		value = value.Field(i)
+	}
+
+	return value, true
+}
+
+// Returns a violation message, or an empty string if the rule passes
+func validateConfigRule(rule string, value reflect.Value) string {
+	if rule == "" {
+		return ""
+	}
+
+	name, arg := rule, ""
+	if eq := strings.Index(rule, "="); eq >= 0 {
+		name, arg = rule[:eq], rule[eq+1:]
+	}
+
+	switch name {
+	case "required":
+		if isZeroConfigValue(value) {
+			return "is required"
+		}
+
+	case "min", "max":
+		return validateConfigBound(name, arg, value)
+
+	case "oneof", "oneofci":
+		if isZeroConfigValue(value) {
+			return ""
+		}
+
+		options := strings.Fields(arg)
+		actual := fmt.Sprint(value.Interface())
+		for _, option := range options {
+			if actual == option || (name == "oneofci" && strings.EqualFold(actual, option)) {
+				return ""
+			}
+		}
+		return fmt.Sprintf("must be one of [%s], got %q", strings.Join(options, " "), actual)
+
+	case "url":
+		if isZeroConfigValue(value) {
+			return ""
+		}
+
+		u, err := url.Parse(fmt.Sprint(value.Interface()))
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Sprintf("must be an absolute URL, got %q", value.Interface())
+		}
+
+	case "file":
+		if isZeroConfigValue(value) {
+			return ""
+		}
+
+		path := fmt.Sprint(value.Interface())
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Sprintf("file %q does not exist", path)
+		} else if info.IsDir() {
+			return fmt.Sprintf("%q is a directory, expected a file", path)
+		}
+
+	default:
+		return fmt.Sprintf("unknown validation rule %q", rule)
+	}
+
+	return ""
+}
+
+func validateConfigBound(name, arg string, value reflect.Value) string {
+
+	var cmp int
+	var actual string
+
+	switch {
+	case value.Type() == durationType:
+		bound, err := time.ParseDuration(arg)
+		if err != nil {
+			return fmt.Sprintf("invalid duration bound %s=%s", name, arg)
+		}
+		d := time.Duration(value.Int())
+		cmp, actual = compareInt64(int64(d), int64(bound)), d.String()
+
+	case value.Kind() >= reflect.Int && value.Kind() <= reflect.Int64:
+		bound, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return fmt.Sprintf("invalid bound %s=%s", name, arg)
+		}
+		cmp, actual = compareInt64(value.Int(), bound), strconv.FormatInt(value.Int(), 10)
+
+	case value.Kind() >= reflect.Uint && value.Kind() <= reflect.Uintptr:
+		bound, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil {
+			return fmt.Sprintf("invalid bound %s=%s", name, arg)
+		}
+		switch {
+		case value.Uint() < bound:
+			cmp = -1
+		case value.Uint() > bound:
+			cmp = 1
+		}
+		actual = strconv.FormatUint(value.Uint(), 10)
+
+	case value.Kind() == reflect.Float32 || value.Kind() == reflect.Float64:
+		bound, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return fmt.Sprintf("invalid bound %s=%s", name, arg)
+		}
+		switch {
+		case value.Float() < bound:
+			cmp = -1
+		case value.Float() > bound:
+			cmp = 1
+		}
+		actual = strconv.FormatFloat(value.Float(), 'g', -1, 64)
+
+	case value.Kind() == reflect.String || value.Kind() == reflect.Slice || value.Kind() == reflect.Map || value.Kind() == reflect.Array:
+		bound, err := strconv.Atoi(arg)
+		if err != nil {
+			return fmt.Sprintf("invalid length bound %s=%s", name, arg)
+		}
+		cmp = compareInt64(int64(value.Len()), int64(bound))
+		if name == "min" && cmp < 0 {
+			return fmt.Sprintf("length must be at least %d, got %d", bound, value.Len())
+		} else if name == "max" && cmp > 0 {
+			return fmt.Sprintf("length must be at most %d, got %d", bound, value.Len())
+		}
+		return ""
+
+	default:
+		return fmt.Sprintf("%s is not supported for type %s", name, value.Type())
+	}
+
+	if name == "min" && cmp < 0 {
+		return fmt.Sprintf("must be at least %s, got %s", arg, actual)
+	} else if name == "max" && cmp > 0 {
+		return fmt.Sprintf("must be at most %s, got %s", arg, actual)
+	}
+
+	return ""
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+func isZeroConfigValue(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Slice, reflect.Map:
+		return value.Len() == 0
+	}
+
+	return value.IsZero()
+}
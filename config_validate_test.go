@@ -0,0 +1,67 @@
+package october
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestValidateConfigRule(t *testing.T) {
+	tests := []struct {
+		rule  string
+		value interface{}
+		valid bool
+	}{
+		{"required", "", false},
+		{"required", "x", true},
+		{"min=1", 0, false},
+		{"min=1", 1, true},
+		{"max=65535", 65536, false},
+		{"min=1s", 500 * time.Millisecond, false},
+		{"min=1s", time.Second, true},
+		{"max=3", "abcd", false},
+		{"oneof=debug info", "info", true},
+		{"oneof=debug info", "INFO", false},
+		{"oneof=debug info", "", true},
+		{"oneofci=debug info", "INFO", true},
+		{"oneofci=debug info", "Debug", true},
+		{"oneofci=debug info", "trace", false},
+		{"url", "https://example.com", true},
+		{"url", "example.com", false},
+		{"file", "/does/not/exist", false},
+		{"unknown", "x", false},
+	}
+
+	for _, test := range tests {
+		msg := validateConfigRule(test.rule, reflect.ValueOf(test.value))
+		if valid := msg == ""; valid != test.valid {
+			t.Errorf("%s with %#v: got valid %t (%q), want %t", test.rule, test.value, valid, msg, test.valid)
+		}
+	}
+}
+
+func TestValidateOctoberConfig(t *testing.T) {
+	cfg := DefaultOctoberConfig(PROD)
+	cfg.Log.Level = "INFO"
+	cfg.Log.Encoding = "JSON"
+
+	err := ValidateConfig(&cfg, octoberEnvPrefix)
+	if err != nil {
+		t.Errorf("uppercase log level and encoding rejected: %v", err)
+	}
+
+	buffer := -1
+	cfg.Log.Buffer = &buffer
+	err = ValidateConfig(&cfg, octoberEnvPrefix)
+	if err == nil {
+		t.Error("negative log buffer behind a pointer accepted")
+	}
+	cfg.Log.Buffer = nil
+
+	_, err = InitServiceWithConfig(OctoberConfig{})
+	var validationErr *ConfigValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("empty config not rejected with a validation error, got %v", err)
+	}
+}
@@ -0,0 +1,332 @@
+package october
+
+import (
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// How long to wait for a burst of file events (editors, atomic renames) to settle before reloading
+const configWatchDebounce = 100 * time.Millisecond
+
+// How often directories of config files that can't be watched, because they don't exist (yet), are retried
+const configWatchRetry = 5 * time.Second
+
+// Receives the previous and new config after a successful reload.
+// Both are pointers of the same type passed to WatchConfig, and must not be modified.
+type ConfigChangeFunc func(old, new interface{})
+
+// Keeps a live config up to date with its config files, see Configurator.WatchConfig
+type ConfigWatcher struct {
+	configurator *Configurator
+	prefix       string
+	configType   reflect.Type
+
+	current    atomic.Value
+	reloadLock sync.Mutex // Serializes reloads, so subscribers see changes in order
+
+	subscribersLock sync.Mutex
+	subscribers     []ConfigChangeFunc
+
+	fileWatcher     *fsnotify.Watcher
+	files           map[string]string // Config files to their resolved path, only used by watch
+	dirs            map[string]bool   // Directories of config files, true while watched, only used by watch
+	providerChanges chan struct{}
+	signals         chan os.Signal // See ReloadOnSignal
+	stop            chan struct{}
+	stopOnce        sync.Once
+}
+
+// Decodes decodeInto, then keeps watching for changes to the files read by ReadConfigFiles,
+// or changes reported by any WatchableConfigProvider. See ReloadOnSignal to also reload on a SIGHUP.
+// On a change the config files and providers are re-read and decoded into a fresh struct of the same type as decodeInto.
+// The live config is only swapped, and subscribers called, when the new config decodes and validates.
+// decodeInto is the initial live config, and must not be modified afterwards.
+func (c *Configurator) WatchConfig(decodeInto interface{}, prefix string) (*ConfigWatcher, error) {
+	configType := reflect.TypeOf(decodeInto)
+	if configType == nil || configType.Kind() != reflect.Ptr || configType.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("WatchConfig requires a pointer to a struct")
+	}
+
+	err := c.DecodeEnv(decodeInto, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	fileWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	files := make(map[string]string)
+	for _, path := range c.configFiles {
+		path = filepath.Clean(path)
+		files[path] = resolveConfigPath(path)
+	}
+	providers := make([]ConfigProvider, len(c.providers))
+	copy(providers, c.providers)
+	c.mu.Unlock()
+
+	w := &ConfigWatcher{
+		configurator:    c,
+		prefix:          prefix,
+		configType:      configType.Elem(),
+		fileWatcher:     fileWatcher,
+		files:           files,
+		dirs:            make(map[string]bool),
+		providerChanges: make(chan struct{}, 1),
+		signals:         make(chan os.Signal, 1),
+		stop:            make(chan struct{}),
+	}
+	w.current.Store(decodeInto)
+
+	// Watch directories rather than files, so files replaced by rename (including kubernetes ConfigMap updates) are seen
+	for path := range files {
+		w.dirs[filepath.Dir(path)] = false
+	}
+
+	_, err = w.watchDirs()
+	if err != nil {
+		fileWatcher.Close()
+		return nil, err
+	}
+
+	for _, provider := range providers {
+		watchable, ok := provider.(WatchableConfigProvider)
+		if !ok {
+			continue
+		}
+
+		err = watchable.Watch(w.providerChanged, w.stop)
+		if err != nil {
+			w.Close()
+			return nil, errors.Wrapf(err, "Failed to watch config provider %s", provider.Name())
+		}
+	}
+
+	go w.watch()
+
+	return w, nil
+}
+
+func (c *Configurator) MustWatchConfig(decodeInto interface{}, prefix string) *ConfigWatcher {
+	w, err := c.WatchConfig(decodeInto, prefix)
+	if err != nil {
+		panic(err)
+	}
+
+	return w
+}
+
+// Returns the live config, a pointer of the same type passed to WatchConfig
+func (w *ConfigWatcher) Current() interface{} {
+	return w.current.Load()
+}
+
+// Register f to be called after every successful reload
+func (w *ConfigWatcher) OnChange(f ConfigChangeFunc) {
+	w.subscribersLock.Lock()
+	defer w.subscribersLock.Unlock()
+
+	w.subscribers = append(w.subscribers, f)
+}
+
+// Also reload when the process receives any of signals, SIGHUP if none are given.
+// Signal handling is process wide, so this is left to the application to opt into.
+func (w *ConfigWatcher) ReloadOnSignal(signals ...os.Signal) {
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGHUP}
+	}
+
+	signal.Notify(w.signals, signals...)
+}
+
+// Re-read config files and providers, and decode into a fresh config.
+// If the new config fails to load or validate the live config is kept and the error returned.
+// Reloads are serialized, subscribers of one reload are called before the next reload starts.
+func (w *ConfigWatcher) Reload() error {
+	w.reloadLock.Lock()
+	defer w.reloadLock.Unlock()
+
+	fresh := reflect.New(w.configType).Interface()
+
+	c := w.configurator
+	c.mu.Lock()
+	err := c.loadConfigLayers()
+	if err == nil {
+		err = c.decode(fresh, w.prefix)
+	}
+	c.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	old := w.current.Load()
+	w.current.Store(fresh)
+
+	w.subscribersLock.Lock()
+	subscribers := make([]ConfigChangeFunc, len(w.subscribers))
+	copy(subscribers, w.subscribers)
+	w.subscribersLock.Unlock()
+
+	for _, f := range subscribers {
+		f(old, fresh)
+	}
+
+	return nil
+}
+
+// Stop watching, the live config remains available from Current
+func (w *ConfigWatcher) Close() error {
+	var err error
+	w.stopOnce.Do(func() {
+		signal.Stop(w.signals)
+		close(w.stop)
+		err = w.fileWatcher.Close()
+	})
+
+	return err
+}
+
+// Queue a reload, without blocking the provider
+func (w *ConfigWatcher) providerChanged() {
+	select {
+	case w.providerChanges <- struct{}{}:
+	default:
+	}
+}
+
+// Watches directories of config files that aren't watched yet, returning true if any were added.
+// Missing directories are skipped, they're retried while watching in case they're created later.
+func (w *ConfigWatcher) watchDirs() (bool, error) {
+	added := false
+	for dir, watched := range w.dirs {
+		if watched {
+			continue
+		}
+
+		err := w.fileWatcher.Add(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return added, errors.Wrapf(err, "Failed to watch config directory %s", dir)
+		}
+		w.dirs[dir] = true
+		added = true
+	}
+
+	return added, nil
+}
+
+// Returns true if event changes a config file. Other files in the same directories, such as editor swap files, are ignored.
+func (w *ConfigWatcher) configEvent(event fsnotify.Event) bool {
+	name := filepath.Clean(event.Name)
+
+	if _, ok := w.dirs[name]; ok && event.Op&(fsnotify.Remove|fsnotify.Rename) != 0 {
+		// The directory itself was replaced, its watch is gone until it's watched again
+		w.dirs[name] = false
+		_, _ = w.watchDirs()
+		return true
+	}
+
+	if _, ok := w.files[name]; ok {
+		w.files[name] = resolveConfigPath(name)
+		return event.Op != fsnotify.Chmod
+	}
+
+	// Symlinked files, such as kubernetes ConfigMap keys, change when their target is swapped without an event naming them
+	changed := false
+	for path, resolved := range w.files {
+		if current := resolveConfigPath(path); current != resolved {
+			w.files[path] = current
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+func (w *ConfigWatcher) watch() {
+	logger := zap.L().Named("OCTOBER")
+
+	debounce := time.NewTimer(configWatchDebounce)
+	debounce.Stop()
+
+	retry := time.NewTicker(configWatchRetry)
+	defer retry.Stop()
+
+	reload := func(reason string) {
+		logger.Info("Reloading config", zap.String("reason", reason))
+
+		err := w.Reload()
+		if err != nil {
+			logger.Error("Config reload failed, keeping current config", zap.Error(err))
+		}
+	}
+
+	for {
+		select {
+		case <-w.stop:
+			debounce.Stop()
+			return
+
+		case sig := <-w.signals:
+			reload(sig.String())
+
+		case event, ok := <-w.fileWatcher.Events:
+			if !ok {
+				return
+			}
+
+			if w.configEvent(event) {
+				debounce.Reset(configWatchDebounce)
+			}
+
+		case <-w.providerChanges:
+			debounce.Reset(configWatchDebounce)
+
+		case <-debounce.C:
+			reload("config changed")
+
+		case <-retry.C:
+			added, err := w.watchDirs()
+			if err != nil {
+				logger.Warn("Config file watcher error", zap.Error(err))
+			}
+
+			// Files may have been created along with their directory
+			if added {
+				debounce.Reset(configWatchDebounce)
+			}
+
+		case err, ok := <-w.fileWatcher.Errors:
+			if !ok {
+				return
+			}
+
+			logger.Warn("Config file watcher error", zap.Error(err))
+		}
+	}
+}
+
+// Returns path with symlinks resolved, or path itself if it can't be resolved
+func resolveConfigPath(path string) string {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return path
+	}
+
+	return resolved
+}
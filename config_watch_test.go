@@ -0,0 +1,173 @@
+package october
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testWatchConfig struct {
+	Name string `october:"name"`
+}
+
+func writeTestFile(t *testing.T, path, contents string) {
+	t.Helper()
+
+	err := ioutil.WriteFile(path, []byte(contents), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func watchTestConfig(t *testing.T, path string) (*ConfigWatcher, chan string) {
+	t.Helper()
+
+	c := NewEnvConfigurator()
+	err := c.ReadConfigFiles(path, LOCAL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := c.WatchConfig(&testWatchConfig{}, "TESTWATCH")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { w.Close() })
+
+	changes := make(chan string, 10)
+	w.OnChange(func(old, new interface{}) {
+		changes <- new.(*testWatchConfig).Name
+	})
+
+	return w, changes
+}
+
+func expectReload(t *testing.T, changes chan string, want string) {
+	t.Helper()
+
+	select {
+	case name := <-changes:
+		if name != want {
+			t.Errorf("reloaded name %q, want %q", name, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no reload, want name %q", want)
+	}
+}
+
+func expectNoReload(t *testing.T, changes chan string) {
+	t.Helper()
+
+	select {
+	case name := <-changes:
+		t.Errorf("unexpected reload, name %q", name)
+	case <-time.After(5 * configWatchDebounce):
+	}
+}
+
+func TestConfigWatcherFiltersEvents(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	writeTestFile(t, path, "name: first\n")
+
+	w, changes := watchTestConfig(t, path)
+	if name := w.Current().(*testWatchConfig).Name; name != "first" {
+		t.Fatalf("initial name %q, want first", name)
+	}
+
+	// Editor swap files and other unrelated files don't reload
+	writeTestFile(t, filepath.Join(dir, ".config.yaml.swp"), "swap")
+	writeTestFile(t, filepath.Join(dir, "other.txt"), "other")
+	expectNoReload(t, changes)
+
+	writeTestFile(t, path, "name: second\n")
+	expectReload(t, changes, "second")
+
+	// Atomic replacement by rename
+	tmp := filepath.Join(dir, "config.yaml.tmp")
+	writeTestFile(t, tmp, "name: third\n")
+	err := os.Rename(tmp, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectReload(t, changes, "third")
+
+	writeTestFile(t, path, "name: fourth\n")
+	expectReload(t, changes, "fourth")
+}
+
+func TestConfigWatcherSymlinkSwap(t *testing.T) {
+	// Same layout as a kubernetes ConfigMap volume, config.yaml -> ..data/config.yaml, ..data -> a timestamped dir
+	dir := t.TempDir()
+	for _, version := range []string{"v1", "v2"} {
+		err := os.Mkdir(filepath.Join(dir, version), 0700)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeTestFile(t, filepath.Join(dir, version, "config.yaml"), "name: "+version+"\n")
+	}
+
+	err := os.Symlink("v1", filepath.Join(dir, "..data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Symlink(filepath.Join("..data", "config.yaml"), filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, changes := watchTestConfig(t, filepath.Join(dir, "config.yaml"))
+
+	err = os.Symlink("v2", filepath.Join(dir, "..data_tmp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectReload(t, changes, "v2")
+}
+
+func TestConfigWatcherSerializesReload(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	writeTestFile(t, path, "name: first\n")
+
+	w, _ := watchTestConfig(t, path)
+
+	var lock sync.Mutex
+	running := 0
+	overlapped := false
+	w.OnChange(func(old, new interface{}) {
+		lock.Lock()
+		running++
+		overlapped = overlapped || running > 1
+		lock.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		lock.Lock()
+		running--
+		lock.Unlock()
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := w.Reload(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if overlapped {
+		t.Error("subscribers of concurrent reloads overlapped")
+	}
+}
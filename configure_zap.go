@@ -1,120 +1,235 @@
 package october
 
 import (
+	"io"
+	"strings"
+	"time"
+
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
 func NewZapLogger(mode Mode) (*zap.Logger, error) {
+	return NewZapLoggerWithConfig(mode, LogConfig{})
+}
 
-	var zapConfig zap.Config
-
-	switch mode {
-	case LOCAL:
-
-		atom := zap.NewAtomicLevelAt(zap.DebugLevel)
-		zapConfig = zap.Config{
-			Level:             atom,
-			Development:       true,
-			DisableCaller:     false,
-			DisableStacktrace: false,
-			Sampling:          nil,
-			Encoding:          "console",
-			EncoderConfig: zapcore.EncoderConfig{
-				TimeKey:        "ts",
-				LevelKey:       "level",
-				NameKey:        "logger",
-				CallerKey:      "caller",
-				MessageKey:     "msg",
-				StacktraceKey:  "stacktrace",
-				LineEnding:     zapcore.DefaultLineEnding,
-				EncodeLevel:    zapcore.CapitalColorLevelEncoder,
-				EncodeTime:     zapcore.ISO8601TimeEncoder,
-				EncodeDuration: zapcore.SecondsDurationEncoder,
-				EncodeCaller:   zapcore.ShortCallerEncoder,
-			},
-			OutputPaths:      []string{"stdout"},
-			ErrorOutputPaths: []string{"stderr"},
-		}
+// Build a logger with the defaults for mode, overridden by any values set in logConfig
+func NewZapLoggerWithConfig(mode Mode, logConfig LogConfig) (*zap.Logger, error) {
+	logger, _, err := NewZapLoggerWithLevels(mode, logConfig)
+	return logger, err
+}
 
-	case DEV:
-
-		atom := zap.NewAtomicLevelAt(zap.DebugLevel)
-		zapConfig = zap.Config{
-			Level:             atom,
-			Development:       true,
-			DisableCaller:     false,
-			DisableStacktrace: false,
-			Sampling:          nil,
-			Encoding:          "json",
-			EncoderConfig: zapcore.EncoderConfig{
-				TimeKey:        "ts",
-				LevelKey:       "level",
-				NameKey:        "logger",
-				CallerKey:      "caller",
-				MessageKey:     "msg",
-				StacktraceKey:  "stacktrace",
-				LineEnding:     zapcore.DefaultLineEnding,
-				EncodeLevel:    zapcore.CapitalLevelEncoder,
-				EncodeTime:     zapcore.EpochMillisTimeEncoder,
-				EncodeDuration: zapcore.SecondsDurationEncoder,
-				EncodeCaller:   zapcore.ShortCallerEncoder,
-			},
-			OutputPaths:      []string{"stdout"},
-			ErrorOutputPaths: []string{"stderr"},
-		}
+// Same as NewZapLoggerWithConfig, also returning the logger's levels for adjusting at runtime
+func NewZapLoggerWithLevels(mode Mode, logConfig LogConfig) (*zap.Logger, *LogLevels, error) {
+	built, err := buildZapLogger(mode, logConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return built.logger, built.levels, nil
+}
+
+// A logger along with the parts of it October exposes on the admin server
+type zapLogger struct {
+	logger *zap.Logger
+	levels *LogLevels
+	buffer *LogBuffer  // nil if the buffer is disabled
+	sinks  []io.Closer // Files and sockets of LogConfig.Sinks, closed once the logger is replaced
+}
 
-	case STAGE:
-		atom := zap.NewAtomicLevelAt(zap.DebugLevel)
-		zapConfig = zap.Config{
-			Level:             atom,
-			Development:       true,
-			DisableCaller:     false,
-			DisableStacktrace: false,
-			Sampling:          nil,
-			Encoding:          "json",
-			EncoderConfig: zapcore.EncoderConfig{
-				TimeKey:        "ts",
-				LevelKey:       "level",
-				NameKey:        "logger",
-				CallerKey:      "caller",
-				MessageKey:     "msg",
-				StacktraceKey:  "stacktrace",
-				LineEnding:     zapcore.DefaultLineEnding,
-				EncodeLevel:    zapcore.CapitalLevelEncoder,
-				EncodeTime:     zapcore.EpochMillisTimeEncoder,
-				EncodeDuration: zapcore.SecondsDurationEncoder,
-				EncodeCaller:   zapcore.ShortCallerEncoder,
-			},
-			OutputPaths:      []string{"stdout"},
-			ErrorOutputPaths: []string{"stderr"},
+func buildZapLogger(mode Mode, logConfig LogConfig) (*zapLogger, error) {
+
+	zapConfig := zapConfigForMode(mode)
+
+	if logConfig.Level != "" {
+		var level zapcore.Level
+		err := level.UnmarshalText([]byte(strings.ToLower(logConfig.Level)))
+		if err != nil {
+			return nil, err
 		}
-	case PROD:
-		atom := zap.NewAtomicLevelAt(zap.DebugLevel)
-		zapConfig = zap.Config{
-			Level:             atom,
-			Development:       true,
-			DisableCaller:     false,
-			DisableStacktrace: false,
-			Sampling:          nil,
-			Encoding:          "json",
-			EncoderConfig: zapcore.EncoderConfig{
-				TimeKey:        "ts",
-				LevelKey:       "level",
-				NameKey:        "logger",
-				CallerKey:      "caller",
-				MessageKey:     "msg",
-				StacktraceKey:  "stacktrace",
-				LineEnding:     zapcore.DefaultLineEnding,
-				EncodeLevel:    zapcore.CapitalLevelEncoder,
-				EncodeTime:     zapcore.EpochMillisTimeEncoder,
-				EncodeDuration: zapcore.SecondsDurationEncoder,
-				EncodeCaller:   zapcore.ShortCallerEncoder,
-			},
-			OutputPaths:      []string{"stdout"},
-			ErrorOutputPaths: []string{"stderr"},
+		zapConfig.Level = zap.NewAtomicLevelAt(level)
+	}
+
+	if logConfig.Encoding != "" {
+		zapConfig.Encoding = strings.ToLower(logConfig.Encoding)
+	}
+
+	encoderConfig, err := logEncoderConfig(zapConfig.Encoding, logConfig)
+	if err != nil {
+		return nil, err
+	}
+	zapConfig.EncoderConfig = encoderConfig
+
+	if logConfig.Development != nil {
+		zapConfig.Development = *logConfig.Development
+	}
+
+	zapConfig.Sampling = logSamplingConfig(mode, logConfig)
+
+	if logConfig.Caller != nil {
+		zapConfig.DisableCaller = !*logConfig.Caller
+	}
+
+	if len(logConfig.OutputPaths) > 0 {
+		zapConfig.OutputPaths = logConfig.OutputPaths
+	}
+
+	if len(logConfig.ErrorOutputPaths) > 0 {
+		zapConfig.ErrorOutputPaths = logConfig.ErrorOutputPaths
+	}
+
+	var opts []zap.Option
+
+	// zap only chooses between warn (development) and error, so stack traces are added here instead
+	stacktraceLevel := zapcore.ErrorLevel
+	if zapConfig.Development {
+		stacktraceLevel = zapcore.WarnLevel
+	}
+
+	if logConfig.StacktraceLevel != "" {
+		err := stacktraceLevel.UnmarshalText([]byte(strings.ToLower(logConfig.StacktraceLevel)))
+		if err != nil {
+			return nil, err
 		}
 	}
 
-	return zapConfig.Build()
+	zapConfig.DisableStacktrace = true
+	opts = append(opts, zap.AddStacktrace(stacktraceLevel))
+
+	// Only the main output is sampled, sinks and the buffer see every entry
+	if zapConfig.Sampling != nil {
+		sampling := zapConfig.Sampling
+		zapConfig.Sampling = nil
+
+		opts = append(opts, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return zapcore.NewSamplerWithOptions(core, time.Second, sampling.Initial, sampling.Thereafter)
+		}))
+	}
+
+	built := &zapLogger{}
+
+	teeCores, sinks, err := newLogSinkCores(logConfig.Sinks, zapConfig.Encoding, logConfig)
+	if err != nil {
+		return nil, err
+	}
+	built.sinks = sinks
+
+	bufferSize := mode.Policy().LogBuffer
+	if logConfig.Buffer != nil {
+		bufferSize = *logConfig.Buffer
+	}
+
+	if bufferSize > 0 {
+		built.buffer = NewLogBuffer(bufferSize)
+		teeCores = append(teeCores, built.buffer.Core(zapcore.DebugLevel))
+	}
+
+	if len(teeCores) > 0 {
+		opts = append(opts, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return zapcore.NewTee(append([]zapcore.Core{core}, teeCores...)...)
+		}))
+	}
+
+	// Counts entries written to any output. Below the limits, so dropped entries aren't counted and summaries are.
+	registerLogMetrics()
+	opts = append(opts, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return zapcore.RegisterHooks(core, countLogEntry)
+	}))
+
+	limits := LogLimits{
+		DedupeWindow: logConfig.DedupeWindow,
+		RateLimit:    logConfig.RateLimit,
+		RateBurst:    logConfig.RateBurst,
+	}
+	opts = append(opts, zap.WrapCore(limits.WrapCore))
+
+	// Levels are filtered by LogLevels, so the underlying core must enable everything
+	built.levels = NewLogLevels(zapConfig.Level.Level())
+	zapConfig.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+	opts = append(opts, zap.WrapCore(built.levels.WrapCore))
+
+	built.logger, err = zapConfig.Build(opts...)
+	if err != nil {
+		closeLogSinks(built.sinks)
+		return nil, err
+	}
+
+	return built, nil
+}
+
+func zapConfigForMode(mode Mode) zap.Config {
+
+	policy := mode.Policy()
+
+	encoding := policy.LogEncoding
+	if encoding == "" {
+		encoding = LogEncodingJSON
+	}
+
+	// Unsupported encodings are reported when the logger is built
+	encoderConfig, _ := logEncoderConfig(encoding, LogConfig{})
+
+	return zap.Config{
+		Level:             zap.NewAtomicLevelAt(policy.LogLevel),
+		Development:       policy.LogDevelopment,
+		DisableCaller:     false,
+		DisableStacktrace: false,
+		Sampling:          logSamplingConfig(mode, LogConfig{}),
+		Encoding:          encoding,
+		EncoderConfig:     encoderConfig,
+		OutputPaths:       []string{"stdout"},
+		ErrorOutputPaths:  []string{"stderr"},
+	}
+}
+
+// Returns the sampling of a logger, nil if entries aren't sampled.
+// The mode decides if entries are sampled unless logConfig does, either way logConfig's rates apply, falling back to the defaults.
+func logSamplingConfig(mode Mode, logConfig LogConfig) *zap.SamplingConfig {
+	sampling := mode.Policy().LogSampling
+	if logConfig.Sampling != nil {
+		sampling = *logConfig.Sampling
+	}
+
+	if !sampling {
+		return nil
+	}
+
+	config := &zap.SamplingConfig{
+		Initial:    defaultSamplingInitial,
+		Thereafter: defaultSamplingThereafter,
+	}
+	if logConfig.SamplingInitial > 0 {
+		config.Initial = logConfig.SamplingInitial
+	}
+	if logConfig.SamplingThereafter > 0 {
+		config.Thereafter = logConfig.SamplingThereafter
+	}
+
+	return config
+}
+
+// Per second, see zap.SamplingConfig. Matches zap.NewProductionConfig
+const (
+	defaultSamplingInitial    = 100
+	defaultSamplingThereafter = 100
+)
+
+// Returns the encoder for a named time format, or a time.Format layout
+func zapTimeEncoder(format string) zapcore.TimeEncoder {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "iso8601":
+		return zapcore.ISO8601TimeEncoder
+	case "rfc3339":
+		return zapcore.RFC3339TimeEncoder
+	case "rfc3339nano":
+		return zapcore.RFC3339NanoTimeEncoder
+	case "epoch":
+		return zapcore.EpochTimeEncoder
+	case "epoch_millis", "millis":
+		return zapcore.EpochMillisTimeEncoder
+	case "epoch_nanos", "nanos":
+		return zapcore.EpochNanosTimeEncoder
+	}
+
+	return zapcore.TimeEncoderOfLayout(format)
 }
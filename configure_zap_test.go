@@ -0,0 +1,36 @@
+package october
+
+import (
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestLogSamplingConfig(t *testing.T) {
+	enabled, disabled := true, false
+	defaults := &zap.SamplingConfig{Initial: defaultSamplingInitial, Thereafter: defaultSamplingThereafter}
+
+	tests := []struct {
+		name      string
+		mode      Mode
+		logConfig LogConfig
+		want      *zap.SamplingConfig
+	}{
+		{"mode without sampling", LOCAL, LogConfig{}, nil},
+		{"mode with sampling", PROD, LogConfig{}, defaults},
+		{"mode with configured rates", PROD, LogConfig{SamplingInitial: 10, SamplingThereafter: 5}, &zap.SamplingConfig{Initial: 10, Thereafter: 5}},
+		{"enabled without rates", LOCAL, LogConfig{Sampling: &enabled}, defaults},
+		{"enabled with one rate", LOCAL, LogConfig{Sampling: &enabled, SamplingThereafter: 20}, &zap.SamplingConfig{Initial: defaultSamplingInitial, Thereafter: 20}},
+		{"disabled", PROD, LogConfig{Sampling: &disabled, SamplingInitial: 10}, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := logSamplingConfig(test.mode, test.logConfig)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package october
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Files loaded by DotEnv and Configurator.WithDotEnv when no files are given, later files take precedence
+var DefaultDotEnvFiles = []string{".env", ".env.local"}
+
+var (
+	dotEnvLock sync.Mutex
+	dotEnvKeys = make(map[string]bool) // Variables set from dotenv files, which later loads may replace
+)
+
+// Load KEY=VALUE pairs from dotenv files into the environment. Missing files are skipped.
+// Later files take precedence over earlier ones, but variables set in the real environment are never overridden.
+// Lines may start with export, values may be single or double quoted, and # starts a comment after a quoted value or whitespace.
+func LoadDotEnv(files ...string) error {
+	if len(files) == 0 {
+		files = DefaultDotEnvFiles
+	}
+
+	dotEnvLock.Lock()
+	defer dotEnvLock.Unlock()
+
+	for _, file := range files {
+		values, err := readDotEnv(file)
+		if os.IsNotExist(errors.Cause(err)) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		for key, value := range values {
+			if _, set := os.LookupEnv(key); set && !dotEnvKeys[key] {
+				continue
+			}
+
+			err = os.Setenv(key, value)
+			if err != nil {
+				return err
+			}
+			dotEnvKeys[key] = true
+		}
+	}
+
+	return nil
+}
+
+// Same as LoadDotEnv, only when OCTOBER_MODE is LOCAL or unset. Returns true if files were loaded.
+func LoadDotEnvIfLocal(files ...string) (bool, error) {
+	mode, _ := ModeFromEnv()
+	if mode != LOCAL {
+		return false, nil
+	}
+
+	return true, LoadDotEnv(files...)
+}
+
+// Load dotenv files before every decode when OCTOBER_MODE is LOCAL, see LoadDotEnvIfLocal
+func (c *Configurator) WithDotEnv(files ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(files) == 0 {
+		files = DefaultDotEnvFiles
+	}
+
+	c.dotEnvFiles = files
+}
+
+func readDotEnv(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	values := make(map[string]string)
+
+	scanner := bufio.NewScanner(f)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+
+		eq := strings.Index(line, "=")
+		if eq <= 0 {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, lineNumber)
+		}
+
+		key := strings.TrimSpace(line[:eq])
+		value, err := parseDotEnvValue(strings.TrimSpace(line[eq+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %s", path, lineNumber, err)
+		}
+
+		values[key] = value
+	}
+
+	return values, scanner.Err()
+}
+
+func parseDotEnvValue(raw string) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+
+	switch quote := raw[0]; quote {
+	case '"', '\'':
+		// The value ends at the first unescaped quote, backslashes only escape within double quotes
+		end := -1
+		for i := 1; i < len(raw); i++ {
+			if quote == '"' && raw[i] == '\\' {
+				i++
+				continue
+			}
+			if raw[i] == quote {
+				end = i
+				break
+			}
+		}
+		if end < 0 {
+			return "", fmt.Errorf("unterminated %c quoted value", quote)
+		}
+
+		// Only a comment may follow the closing quote
+		if rest := strings.TrimSpace(raw[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+			return "", fmt.Errorf("unexpected %q after %c quoted value", rest, quote)
+		}
+
+		value := raw[1:end]
+		if quote == '"' {
+			value = strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\"`, `"`, `\\`, `\`).Replace(value)
+		}
+		return value, nil
+	}
+
+	// Unquoted values end at an inline comment
+	if comment := strings.Index(raw, " #"); comment >= 0 {
+		raw = raw[:comment]
+	}
+
+	return strings.TrimSpace(raw), nil
+}
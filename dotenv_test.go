@@ -0,0 +1,120 @@
+package october
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadDotEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     map[string]string
+		wantErr  string
+	}{
+		{"plain", "A=1\nB = two \n", map[string]string{"A": "1", "B": "two"}, ""},
+		{"comments and blank lines", "# comment\n\n  # indented\nA=1\n", map[string]string{"A": "1"}, ""},
+		{"export", "export A=1\nexport  B=2\n", map[string]string{"A": "1", "B": "2"}, ""},
+		{"empty value", "A=\nB=''\nC=\"\"\n", map[string]string{"A": "", "B": "", "C": ""}, ""},
+		{"equals in value", "A=b=c\n", map[string]string{"A": "b=c"}, ""},
+		{"inline comment", "A=1 # one\nB=x#y\n", map[string]string{"A": "1", "B": "x#y"}, ""},
+		{"single quoted", `A='a "b" \n # c'`, map[string]string{"A": `a "b" \n # c`}, ""},
+		{"double quoted", `A="a 'b' # c"`, map[string]string{"A": "a 'b' # c"}, ""},
+		{"double quoted escapes", `A="line\nnext\ttab \"q\" back\\slash"`, map[string]string{"A": "line\nnext\ttab \"q\" back\\slash"}, ""},
+		{"escaped quote at end", `A="a\""`, map[string]string{"A": `a"`}, ""},
+		{"comment after quote", `A="a" # "b"` + "\nB='c'#d\n", map[string]string{"A": "a", "B": "c"}, ""},
+		{"later line wins", "A=1\nA=2\n", map[string]string{"A": "2"}, ""},
+		{"missing equals", "A=1\nB\n", nil, ":2: expected KEY=VALUE"},
+		{"missing key", "=1\n", nil, ":1: expected KEY=VALUE"},
+		{"unterminated double quote", `A="a`, nil, `:1: unterminated " quoted value`},
+		{"unterminated by escaped quote", `A="a\"`, nil, `:1: unterminated " quoted value`},
+		{"unterminated single quote", `A='a`, nil, `:1: unterminated ' quoted value`},
+		{"text after quote", `A="a" b`, nil, `:1: unexpected "b" after " quoted value`},
+		{"second quoted value", `A='a' 'b'`, nil, `:1: unexpected "'b'" after ' quoted value`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".env")
+			writeTestFile(t, path, test.contents)
+
+			values, err := readDotEnv(path)
+			if test.wantErr != "" {
+				if err == nil || !strings.HasSuffix(err.Error(), test.wantErr) {
+					t.Fatalf("got error %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(values, test.want) {
+				t.Errorf("got %q, want %q", values, test.want)
+			}
+		})
+	}
+}
+
+// Unsets variables set by LoadDotEnv once the test ends
+func cleanupDotEnv(t *testing.T, keys ...string) {
+	t.Cleanup(func() {
+		dotEnvLock.Lock()
+		defer dotEnvLock.Unlock()
+
+		for _, key := range keys {
+			os.Unsetenv(key)
+			delete(dotEnvKeys, key)
+		}
+	})
+}
+
+func TestLoadDotEnvKeepsEnvironment(t *testing.T) {
+	dir := t.TempDir()
+	base, local := filepath.Join(dir, ".env"), filepath.Join(dir, ".env.local")
+	writeTestFile(t, base, "TESTDOTENV_SET=file\nTESTDOTENV_EMPTY=file\nTESTDOTENV_BASE=base\nTESTDOTENV_LOCAL=base\n")
+	writeTestFile(t, local, "TESTDOTENV_SET=local\nTESTDOTENV_LOCAL=local\n")
+
+	t.Setenv("TESTDOTENV_SET", "env")
+	t.Setenv("TESTDOTENV_EMPTY", "")
+	cleanupDotEnv(t, "TESTDOTENV_BASE", "TESTDOTENV_LOCAL")
+
+	err := LoadDotEnv(base, local, filepath.Join(dir, "missing.env"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Loading again, as before every decode, keeps the environment and the precedence of later files
+	err = LoadDotEnv(base, local)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"TESTDOTENV_SET":   "env",
+		"TESTDOTENV_EMPTY": "",
+		"TESTDOTENV_BASE":  "base",
+		"TESTDOTENV_LOCAL": "local",
+	}
+	for key, value := range want {
+		if got := os.Getenv(key); got != value {
+			t.Errorf("got %s=%q, want %q", key, got, value)
+		}
+	}
+}
+
+func TestLoadDotEnvError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	writeTestFile(t, path, "TESTDOTENV_BAD=\"a\" b\n")
+
+	err := LoadDotEnv(path)
+	if err == nil || !strings.Contains(err.Error(), path+":1:") {
+		t.Errorf("got error %v, want the file and line", err)
+	}
+	if _, set := os.LookupEnv("TESTDOTENV_BAD"); set {
+		t.Error("variable of a malformed file set")
+	}
+}
@@ -0,0 +1,38 @@
+package october
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Assigns each request a correlation ID, taken from the X-Request-ID header or generated, and echoes it in the response.
+// The request context stores the ID and logger with the ID added, retrieved with RequestID and Logger.
+// The logger is also linked to the request's Cloud Trace trace, see LogConfig.GCPProject.
+func GinRequestID(logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := requestIDOrNew(c.GetHeader(RequestIDHeader))
+
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(withRequestLogger(c.Request.Context(), logger, id, ginTraceFields(c)...))
+
+		c.Next()
+	}
+}
+
+// Adds the request ID set by GinRequestID, if there is one, and the request's trace to logger
+func ginRequestLogger(c *gin.Context, logger *zap.Logger) *zap.Logger {
+	fields := ginTraceFields(c)
+	if id := RequestID(c.Request.Context()); id != "" {
+		fields = append(fields, zap.String(RequestIDField, id))
+	}
+
+	if len(fields) == 0 {
+		return logger
+	}
+
+	return logger.With(fields...)
+}
+
+func ginTraceFields(c *gin.Context) []zap.Field {
+	return requestTraceFields(c.GetHeader(GCPTraceContextHeader), c.GetHeader(TraceparentHeader))
+}
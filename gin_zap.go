@@ -11,6 +11,7 @@ func Ginzap(logger *zap.Logger, timeFormat string, utc bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 		// some evil middlewares modify this values
+		requestLogger := ginRequestLogger(c, logger)
 		path := c.Request.URL.Path
 		query := c.Request.URL.RawQuery
 		c.Next()
@@ -24,10 +25,10 @@ func Ginzap(logger *zap.Logger, timeFormat string, utc bool) gin.HandlerFunc {
 		if len(c.Errors) > 0 {
 			// Append error field if this is an erroneous request.
 			for _, e := range c.Errors.Errors() {
-				logger.Error(e)
+				requestLogger.Error(e)
 			}
 		} else {
-			logger.Info(path,
+			requestLogger.Info(path,
 				zap.Int("status", c.Writer.Status()),
 				zap.String("method", c.Request.Method),
 				zap.String("path", path),
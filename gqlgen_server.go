@@ -7,6 +7,7 @@ import (
 	"github.com/99designs/gqlgen/handler"
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
+	"github.com/vektah/gqlparser/v2/gqlerror"
 	"go.uber.org/zap"
 	"net/http"
 	"sync"
@@ -20,6 +21,9 @@ type GQLGenServer struct {
 	address string
 	port    int
 
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+
 	server *http.Server
 	serverLock *sync.Mutex
 
@@ -38,13 +42,38 @@ func (g *GQLGenServer) playgroundHandler() gin.HandlerFunc {
 }
 
 func (g *GQLGenServer) graphqlHandler() gin.HandlerFunc {
-	h := handler.GraphQL(g.schema, g.options...)
+	policy := g.mode.Policy()
+
+	// Mode options go first, so options from WithOptions override them
+	options := []handler.Option{handler.IntrospectionEnabled(policy.GraphQLIntrospection)}
+	if !policy.ErrorDetail {
+		options = append(options, handler.ErrorPresenter(hideInternalErrorPresenter))
+	}
+	options = append(options, g.options...)
+
+	h := handler.GraphQL(g.schema, options...)
 
 	return func(c *gin.Context) {
 		h.ServeHTTP(c.Writer, c.Request)
 	}
 }
 
+// Presents errors returned by resolvers without their message, which may hold internal details.
+// Errors already built for clients, such as validation errors, are presented as is.
+func hideInternalErrorPresenter(ctx context.Context, err error) *gqlerror.Error {
+	var gqlErr *gqlerror.Error
+	if errors.As(err, &gqlErr) {
+		return gqlErr
+	}
+
+	Logger(ctx).Named("OCTOBER").Error("GraphQL resolver error", zap.Error(err))
+
+	return &gqlerror.Error{
+		Message: "internal system error",
+		Path:    graphql.GetPath(ctx),
+	}
+}
+
 func (g *GQLGenServer) Name() string {
 	return "gql-gen"
 }
@@ -67,7 +96,9 @@ func (g *GQLGenServer) Start() (bool, error) {
 		zap.L().Named("OCTOBER").Fatal("Missing gqlgen executable schema, call WithExecutableSchema before Start ")
 	}
 
-	if g.mode == LOCAL {
+	policy := g.mode.Policy()
+
+	if policy.GinDebug {
 		gin.SetMode(gin.DebugMode)
 	} else {
 		gin.SetMode(gin.ReleaseMode)
@@ -82,6 +113,7 @@ func (g *GQLGenServer) Start() (bool, error) {
 	engine := gin.New()
 
 	middleware := []gin.HandlerFunc{
+		GinRequestID(zap.L()),
 		Ginzap(zap.L(), time.RFC3339, true),
 		RecoveryWithZap(zap.L(), true),
 	}
@@ -90,7 +122,7 @@ func (g *GQLGenServer) Start() (bool, error) {
 
 	engine.Use(middleware...)
 
-	if g.mode == LOCAL {
+	if policy.GraphQLPlayground {
 		engine.GET("/", g.playgroundHandler())
 		zap.L().Info("Starting with GraphQL playground")
 	}
@@ -100,6 +132,8 @@ func (g *GQLGenServer) Start() (bool, error) {
 	g.server = &http.Server{
 		Addr: fmt.Sprintf("%s:%d", g.address, g.port),
 		Handler: engine,
+		ReadTimeout:  g.readTimeout,
+		WriteTimeout: g.writeTimeout,
 	}
 
 	g.serverLock.Unlock()
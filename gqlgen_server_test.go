@@ -0,0 +1,29 @@
+package october
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func TestHideInternalErrorPresenter(t *testing.T) {
+	clientErr := gqlerror.Errorf("invalid input")
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"client error", clientErr, "invalid input"},
+		{"wrapped client error", errors.Wrap(clientErr, "resolving user"), "invalid input"},
+		{"internal error", errors.New("connection refused"), "internal system error"},
+	}
+
+	for _, test := range tests {
+		if got := hideInternalErrorPresenter(context.Background(), test.err); got.Message != test.want {
+			t.Errorf("%s: got message %q, want %q", test.name, got.Message, test.want)
+		}
+	}
+}
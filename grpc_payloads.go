@@ -0,0 +1,283 @@
+package october
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/runtime/protoiface"
+	"google.golang.org/protobuf/runtime/protoimpl"
+)
+
+// Proto field names always redacted from logged payloads, matched case insensitively against field and JSON names
+var DefaultRedactedPayloadFields = []string{
+	"password",
+	"passwd",
+	"secret",
+	"token",
+	"access_token",
+	"refresh_token",
+	"id_token",
+	"api_key",
+	"private_key",
+	"authorization",
+}
+
+// Decides if a payload field is redacted, e.g. by checking a custom field option with proto.GetExtension
+type PayloadRedactFunc func(field protoreflect.FieldDescriptor) bool
+
+// Returns true if payloads of fullMethod should be logged under cfg and mode
+func payloadLoggingEnabled(mode Mode, cfg GRPCPayloadConfig, fullMethod string) bool {
+	enabled := mode.Policy().PayloadLogging
+	if cfg.Enabled != nil {
+		enabled = *cfg.Enabled
+	}
+
+	if !enabled {
+		return false
+	}
+
+	if len(cfg.Methods) == 0 {
+		return true
+	}
+
+	for _, method := range cfg.Methods {
+		method = strings.TrimSpace(method)
+		if method == "" {
+			continue
+		}
+		if !strings.HasPrefix(method, "/") {
+			method = "/" + method
+		}
+
+		// Exact methods, /pkg.Service/ and /pkg.Service all match
+		if fullMethod == method ||
+			(strings.HasSuffix(method, "/") && strings.HasPrefix(fullMethod, method)) ||
+			strings.HasPrefix(fullMethod, method+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Logs request and response payloads of unary calls enabled by cfg, with the request-scoped logger, see Logger.
+// Fields named in DefaultRedactedPayloadFields or cfg.RedactFields, or matched by redact, are redacted.
+func PayloadUnaryServerInterceptor(mode Mode, cfg GRPCPayloadConfig, redact PayloadRedactFunc) grpc.UnaryServerInterceptor {
+	payloads := newPayloadLogger(cfg, redact)
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !payloadLoggingEnabled(mode, cfg, info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		payloads.log(ctx, "server request payload logged as grpc.request.content field", "grpc.request", req)
+
+		resp, err := handler(ctx, req)
+		if err == nil {
+			payloads.log(ctx, "server response payload logged as grpc.response.content field", "grpc.response", resp)
+		}
+
+		return resp, err
+	}
+}
+
+// Same as PayloadUnaryServerInterceptor, for every message received and sent by streaming calls
+func PayloadStreamServerInterceptor(mode Mode, cfg GRPCPayloadConfig, redact PayloadRedactFunc) grpc.StreamServerInterceptor {
+	payloads := newPayloadLogger(cfg, redact)
+
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !payloadLoggingEnabled(mode, cfg, info.FullMethod) {
+			return handler(srv, stream)
+		}
+
+		return handler(srv, &payloadServerStream{ServerStream: grpc_middleware.WrapServerStream(stream), payloads: payloads})
+	}
+}
+
+type payloadServerStream struct {
+	grpc.ServerStream
+	payloads *payloadLogger
+}
+
+func (s *payloadServerStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.payloads.log(s.Context(), "server response payload logged as grpc.response.content field", "grpc.response", m)
+	}
+
+	return err
+}
+
+func (s *payloadServerStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.payloads.log(s.Context(), "server request payload logged as grpc.request.content field", "grpc.request", m)
+	}
+
+	return err
+}
+
+type payloadLogger struct {
+	redactNames map[string]bool
+	redact      PayloadRedactFunc
+	maxSize     int
+}
+
+func newPayloadLogger(cfg GRPCPayloadConfig, redact PayloadRedactFunc) *payloadLogger {
+	p := &payloadLogger{
+		redactNames: make(map[string]bool),
+		redact:      redact,
+		maxSize:     int(cfg.MaxSize),
+	}
+
+	for _, name := range append(append([]string{}, DefaultRedactedPayloadFields...), cfg.RedactFields...) {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "" {
+			p.redactNames[name] = true
+		}
+	}
+
+	return p
+}
+
+func (p *payloadLogger) log(ctx context.Context, msg string, prefix string, payload interface{}) {
+	content, err := p.marshal(payload)
+	if err != nil {
+		Logger(ctx).Warn("Failed to log GRPC payload", zap.String("grpc.payload_type", fmt.Sprintf("%T", payload)), zap.Error(err))
+		return
+	}
+
+	fields := []zap.Field{zap.Reflect(prefix+".content", json.RawMessage(content))}
+
+	if p.maxSize > 0 && len(content) > p.maxSize {
+		// Truncated JSON isn't valid, so it's logged as a string
+		fields = []zap.Field{
+			zap.String(prefix+".content", truncateUTF8(string(content), p.maxSize)),
+			zap.Bool(prefix+".truncated", true),
+			zap.Int(prefix+".size", len(content)),
+		}
+	}
+
+	Logger(ctx).Info(msg, fields...)
+}
+
+// Marshals payload to JSON, with redacted fields replaced
+func (p *payloadLogger) marshal(payload interface{}) ([]byte, error) {
+	message, ok := payload.(proto.Message)
+	if !ok {
+		legacy, ok := payload.(protoiface.MessageV1)
+		if !ok {
+			return json.Marshal(payload)
+		}
+		message = protoimpl.X.ProtoMessageV2Of(legacy)
+	}
+
+	redacted := proto.Clone(message)
+	p.redactMessage(redacted.ProtoReflect())
+
+	return protojson.Marshal(redacted)
+}
+
+func (p *payloadLogger) shouldRedact(field protoreflect.FieldDescriptor) bool {
+	if p.redactNames[strings.ToLower(string(field.Name()))] || p.redactNames[strings.ToLower(field.JSONName())] {
+		return true
+	}
+
+	return p.redact != nil && p.redact(field)
+}
+
+func (p *payloadLogger) redactMessage(message protoreflect.Message) {
+	var redact []protoreflect.FieldDescriptor
+
+	message.Range(func(field protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		switch {
+		case p.shouldRedact(field):
+			redact = append(redact, field)
+		case field.IsList() && field.Message() != nil:
+			list := value.List()
+			for i := 0; i < list.Len(); i++ {
+				p.redactMessage(list.Get(i).Message())
+			}
+		case field.IsMap() && field.MapValue().Message() != nil:
+			value.Map().Range(func(_ protoreflect.MapKey, entry protoreflect.Value) bool {
+				p.redactMessage(entry.Message())
+				return true
+			})
+		case !field.IsList() && !field.IsMap() && field.Message() != nil:
+			p.redactMessage(value.Message())
+		}
+
+		return true
+	})
+
+	// Changed after ranging, as changing fields while ranging is undefined
+	for _, field := range redact {
+		redactField(message, field)
+	}
+}
+
+// Replaces strings and bytes with RedactedConfigValue, clears anything else
+func redactField(message protoreflect.Message, field protoreflect.FieldDescriptor) {
+	redacted := redactedScalar(field.Kind())
+	if field.IsMap() {
+		redacted = redactedScalar(field.MapValue().Kind())
+	}
+
+	if !redacted.IsValid() {
+		message.Clear(field)
+		return
+	}
+
+	switch {
+	case field.IsList():
+		list := message.Mutable(field).List()
+		for i := 0; i < list.Len(); i++ {
+			list.Set(i, redacted)
+		}
+	case field.IsMap():
+		entries := message.Mutable(field).Map()
+		var keys []protoreflect.MapKey
+		entries.Range(func(key protoreflect.MapKey, _ protoreflect.Value) bool {
+			keys = append(keys, key)
+			return true
+		})
+		for _, key := range keys {
+			entries.Set(key, redacted)
+		}
+	default:
+		message.Set(field, redacted)
+	}
+}
+
+func redactedScalar(kind protoreflect.Kind) protoreflect.Value {
+	switch kind {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(RedactedConfigValue)
+	case protoreflect.BytesKind:
+		return protoreflect.ValueOfBytes([]byte(RedactedConfigValue))
+	}
+
+	return protoreflect.Value{}
+}
+
+// Cuts s to at most max bytes, without splitting a UTF-8 character
+func truncateUTF8(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+
+	return s[:max]
+}
@@ -0,0 +1,255 @@
+package october
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Descriptor of a login request, built here as the repo has no generated test protos:
+//
+//	message Credentials { string user = 1; string password = 2; bytes private_key = 3; int64 secret = 4; string note = 5; }
+//	message Login { Credentials credentials = 1; repeated Credentials history = 2; map<string, string> token = 3; string session_id = 4; }
+func testLoginDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	t.Helper()
+
+	field := func(name string, number int32, kind descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label, typeName string) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{
+			Name:   proto.String(name),
+			Number: proto.Int32(number),
+			Type:   kind.Enum(),
+			Label:  label.Enum(),
+		}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+	repeated := descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+
+	file := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("october_payload_test.proto"),
+		Package: proto.String("octobertest"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Credentials"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("user", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, ""),
+					field("password", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, ""),
+					field("private_key", 3, descriptorpb.FieldDescriptorProto_TYPE_BYTES, optional, ""),
+					field("secret", 4, descriptorpb.FieldDescriptorProto_TYPE_INT64, optional, ""),
+					field("note", 5, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, ""),
+				},
+			},
+			{
+				Name: proto.String("Login"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("credentials", 1, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, optional, ".octobertest.Credentials"),
+					field("history", 2, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, repeated, ".octobertest.Credentials"),
+					field("token", 3, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, repeated, ".octobertest.Login.TokenEntry"),
+					field("session_id", 4, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, ""),
+				},
+				NestedType: []*descriptorpb.DescriptorProto{
+					{
+						Name: proto.String("TokenEntry"),
+						Field: []*descriptorpb.FieldDescriptorProto{
+							field("key", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, ""),
+							field("value", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, ""),
+						},
+						Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+					},
+				},
+			},
+		},
+	}
+
+	fd, err := protodesc.NewFile(file, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return fd.Messages().ByName("Login")
+}
+
+func testLogin(t *testing.T) *dynamicpb.Message {
+	t.Helper()
+
+	login := dynamicpb.NewMessage(testLoginDescriptor(t))
+	fields := login.Descriptor().Fields()
+
+	credentials := func(user string) protoreflect.Value {
+		c := login.NewField(fields.ByName("credentials")).Message()
+		cf := c.Descriptor().Fields()
+		c.Set(cf.ByName("user"), protoreflect.ValueOfString(user))
+		c.Set(cf.ByName("password"), protoreflect.ValueOfString("hunter2"))
+		c.Set(cf.ByName("private_key"), protoreflect.ValueOfBytes([]byte("key")))
+		c.Set(cf.ByName("secret"), protoreflect.ValueOfInt64(42))
+		c.Set(cf.ByName("note"), protoreflect.ValueOfString("internal"))
+		return protoreflect.ValueOfMessage(c)
+	}
+
+	login.Set(fields.ByName("credentials"), credentials("ada"))
+
+	history := login.Mutable(fields.ByName("history")).List()
+	history.Append(credentials("grace"))
+
+	tokens := login.Mutable(fields.ByName("token")).Map()
+	tokens.Set(protoreflect.ValueOfString("session").MapKey(), protoreflect.ValueOfString("abc"))
+
+	login.Set(fields.ByName("session_id"), protoreflect.ValueOfString("s-123"))
+
+	return login
+}
+
+func TestPayloadRedaction(t *testing.T) {
+	noteRedacted := func(field protoreflect.FieldDescriptor) bool {
+		return field.Name() == "note"
+	}
+
+	p := newPayloadLogger(GRPCPayloadConfig{RedactFields: []string{" USER ", "sessionId"}}, noteRedacted)
+	login := testLogin(t)
+
+	content, err := p.marshal(login)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, leaked := range []string{"hunter2", "ada", "grace", "internal", "abc", "s-123", "42"} {
+		if strings.Contains(string(content), leaked) {
+			t.Errorf("%q not redacted from %s", leaked, content)
+		}
+	}
+
+	var decoded struct {
+		Credentials map[string]interface{}
+		History     []map[string]interface{}
+		Token       map[string]string
+		SessionID   string `json:"sessionId"`
+	}
+	err = json.Unmarshal(content, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.Credentials["password"] != RedactedConfigValue || decoded.History[0]["user"] != RedactedConfigValue {
+		t.Errorf("strings not replaced with %s: %s", RedactedConfigValue, content)
+	}
+	if _, ok := decoded.Credentials["secret"]; ok {
+		t.Errorf("redacted number not cleared: %s", content)
+	}
+	if decoded.Token["session"] != RedactedConfigValue || decoded.SessionID != RedactedConfigValue {
+		t.Errorf("map values or field matched by JSON name not redacted: %s", content)
+	}
+
+	// The logged message itself is left alone
+	password := login.Get(login.Descriptor().Fields().ByName("credentials")).Message()
+	if got := password.Get(password.Descriptor().Fields().ByName("password")).String(); got != "hunter2" {
+		t.Errorf("payload changed by redaction, password is %q", got)
+	}
+}
+
+func TestPayloadTruncation(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxSize   ByteSize
+		truncated bool
+	}{
+		{"unlimited", 0, false},
+		{"under max size", 1 << 20, false},
+		{"over max size", 64, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.DebugLevel)
+			ctx := WithLogger(context.Background(), zap.New(core))
+
+			interceptor := PayloadUnaryServerInterceptor(LOCAL, GRPCPayloadConfig{MaxSize: test.maxSize}, nil)
+			_, err := interceptor(ctx, testLogin(t), &grpc.UnaryServerInfo{FullMethod: "/octobertest.Auth/Login"},
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					return req, nil
+				})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			entries := logs.All()
+			if len(entries) != 2 {
+				t.Fatalf("got %d entries, want the request and response", len(entries))
+			}
+
+			fields := entries[0].ContextMap()
+			if truncated := fields["grpc.request.truncated"] == true; truncated != test.truncated {
+				t.Fatalf("got truncated %t, want %t: %v", truncated, test.truncated, fields)
+			}
+			if !test.truncated {
+				return
+			}
+
+			content, _ := fields["grpc.request.content"].(string)
+			if len(content) > int(test.maxSize) || fields["grpc.request.size"].(int64) <= int64(test.maxSize) {
+				t.Errorf("got %d bytes of content and size %v, max size %d", len(content), fields["grpc.request.size"], test.maxSize)
+			}
+		})
+	}
+}
+
+func TestTruncateUTF8(t *testing.T) {
+	tests := []struct {
+		s    string
+		max  int
+		want string
+	}{
+		{"hello", 10, "hello"},
+		{"hello", 3, "hel"},
+		{"héllo", 2, "h"},
+		{"日本", 4, "日"},
+		{"日本", 2, ""},
+	}
+
+	for _, test := range tests {
+		if got := truncateUTF8(test.s, test.max); got != test.want {
+			t.Errorf("truncateUTF8(%q, %d) = %q, want %q", test.s, test.max, got, test.want)
+		}
+	}
+}
+
+func TestPayloadLoggingEnabled(t *testing.T) {
+	disabled := false
+
+	tests := []struct {
+		name   string
+		mode   Mode
+		cfg    GRPCPayloadConfig
+		method string
+		want   bool
+	}{
+		{"mode default on", LOCAL, GRPCPayloadConfig{}, "/pkg.Svc/Get", true},
+		{"mode default off", PROD, GRPCPayloadConfig{}, "/pkg.Svc/Get", false},
+		{"disabled", LOCAL, GRPCPayloadConfig{Enabled: &disabled}, "/pkg.Svc/Get", false},
+		{"exact method", LOCAL, GRPCPayloadConfig{Methods: []string{"pkg.Svc/Get"}}, "/pkg.Svc/Get", true},
+		{"service", LOCAL, GRPCPayloadConfig{Methods: []string{"/pkg.Svc"}}, "/pkg.Svc/Get", true},
+		{"service prefix only", LOCAL, GRPCPayloadConfig{Methods: []string{"/pkg.Sv"}}, "/pkg.Svc/Get", false},
+		{"other method", LOCAL, GRPCPayloadConfig{Methods: []string{"/pkg.Svc/List"}}, "/pkg.Svc/Get", false},
+	}
+
+	for _, test := range tests {
+		if got := payloadLoggingEnabled(test.mode, test.cfg, test.method); got != test.want {
+			t.Errorf("%s: got %t, want %t", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package october
+
+import (
+	"context"
+
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Assigns each call a correlation ID, taken from x-request-id metadata or generated, and echoes it in the response header.
+// The ID is added to the call's grpc_ctxtags, so it's included in grpc_zap logs, and the context stores the ID and
+// the call's logger, retrieved with RequestID and Logger.
+// Must run after the grpc_ctxtags and grpc_zap interceptors.
+func RequestIDUnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(grpcRequestContext(ctx), req)
+	}
+}
+
+// Same as RequestIDUnaryServerInterceptor, for streaming calls
+func RequestIDStreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		wrapped := grpc_middleware.WrapServerStream(stream)
+		wrapped.WrappedContext = grpcRequestContext(stream.Context())
+
+		return handler(srv, wrapped)
+	}
+}
+
+func grpcRequestContext(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	first := func(key string) string {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	id := requestIDOrNew(first(RequestIDMetadataKey))
+	traceFields := requestTraceFields(first(GCPTraceContextHeader), first(TraceparentHeader))
+
+	err := grpc.SetHeader(ctx, metadata.Pairs(RequestIDMetadataKey, id))
+	if err != nil {
+		zap.L().Named("OCTOBER").Warn("Failed to set GRPC request ID header", zap.Error(err))
+	}
+
+	grpc_ctxtags.Extract(ctx).Set(RequestIDField, id)
+
+	// The grpc_zap logger includes the call's tags and fields, so the trace is also in its log of the finished call.
+	// Without grpc_zap, ctxzap returns a no-op logger that enables nothing.
+	ctxzap.AddFields(ctx, traceFields...)
+	logger := ctxzap.Extract(ctx)
+	if logger.Core().Enabled(zap.FatalLevel) {
+		return WithLogger(WithRequestID(ctx, id), logger)
+	}
+
+	return withRequestLogger(ctx, zap.L(), id, traceFields...)
+}
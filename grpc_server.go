@@ -9,8 +9,8 @@ import (
 	"time"
 
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
-	grpc_logging "github.com/grpc-ecosystem/go-grpc-middleware/logging"
 	grpc_zap "github.com/grpc-ecosystem/go-grpc-middleware/logging/zap"
+	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 
@@ -33,6 +33,9 @@ type GRPCServer struct {
 
 	tlsOpt grpc.ServerOption
 
+	payloads      GRPCPayloadConfig
+	payloadRedact PayloadRedactFunc
+
 	externalUnaryInterceptors  []grpc.UnaryServerInterceptor
 	externalStreamInterceptors []grpc.StreamServerInterceptor
 
@@ -103,6 +106,13 @@ func (g *GRPCServer) WithInterceptors(unary []grpc.UnaryServerInterceptor, strea
 	return nil
 }
 
+// Redact payload fields matched by redact from logged payloads, in addition to fields redacted by name
+func (g *GRPCServer) WithPayloadRedaction(redact PayloadRedactFunc) {
+	g.payloadRedact = redact
+
+	g.rebuildServer()
+}
+
 func (g *GRPCServer) WithServerOptions(opt ...grpc.ServerOption) {
 	g.additionalOpts = opt
 
@@ -111,7 +121,7 @@ func (g *GRPCServer) WithServerOptions(opt ...grpc.ServerOption) {
 
 func (g *GRPCServer) rebuildServer() {
 
-	unaryInterceptors, streamInterceptors := GRPCServerInstrumentation(g.mode)
+	unaryInterceptors, streamInterceptors := GRPCServerInstrumentationWithPayloads(g.mode, g.payloads, g.payloadRedact)
 
 
 	unaryInterceptors = append(unaryInterceptors, g.externalUnaryInterceptors...)
@@ -172,27 +182,29 @@ func (g *GRPCServer) Start() (bool, error) {
 
 }
 
+// Stops the server gracefully, waiting for calls and streams to end.
+// Once ctx is done, calls still running are cancelled and ctx's error is returned.
 func (g *GRPCServer) Shutdown(ctx context.Context) error {
 	address := g.Address()
 	zap.S().Named("OCTOBER").Infof("Gracefully stopping controlled GRPC (%s)...", address)
 
-	g.Server.GracefulStop()
-
-	return nil
-}
-
-
-func serverPayloadDecider(m Mode) grpc_logging.ServerPayloadLoggingDecider {
-
-	if m == LOCAL {
-		return func(ctx context.Context, fullMethodName string, servingObject interface{}) bool {
-			return true
-		}
+	stopped := make(chan struct{})
+	go func() {
+		g.Server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		zap.S().Named("OCTOBER").Warnf("Controlled GRPC (%s) did not stop gracefully in time, cancelling remaining calls", address)
+		g.Server.Stop()
+		return ctx.Err()
 	}
-
-	return nil
 }
 
+
 func grpcZapOptions(mode Mode) []grpc_zap.Option {
 	return []grpc_zap.Option{
 		grpc_zap.WithLevels(grpc_zap.DefaultCodeToLevel),
@@ -223,6 +235,11 @@ func GRPCTLSCreds(crt, key string) (grpc.ServerOption, error) {
 }
 
 func GRPCServerInstrumentation(mode Mode) ([]grpc.UnaryServerInterceptor, []grpc.StreamServerInterceptor) {
+	return GRPCServerInstrumentationWithPayloads(mode, DefaultOctoberConfig(mode).GRPC.Payloads, nil)
+}
+
+// Same as GRPCServerInstrumentation, logging payloads as configured by payloads, see PayloadUnaryServerInterceptor
+func GRPCServerInstrumentationWithPayloads(mode Mode, payloads GRPCPayloadConfig, redact PayloadRedactFunc) ([]grpc.UnaryServerInterceptor, []grpc.StreamServerInterceptor) {
 
 	loggingOpts := grpcZapOptions(mode)
 
@@ -233,15 +250,23 @@ func GRPCServerInstrumentation(mode Mode) ([]grpc.UnaryServerInterceptor, []grpc
 		grpc_prometheus.EnableHandlingTimeHistogram()
 	}*/
 
-	unary = append(unary, grpc_zap.UnaryServerInterceptor(zap.L(), loggingOpts...))
-	stream = append(stream, grpc_zap.StreamServerInterceptor(zap.L(), loggingOpts...))
-
-	payloadDecider := serverPayloadDecider(mode)
-
-	if payloadDecider != nil {
-		unary = append(unary, grpc_zap.PayloadUnaryServerInterceptor(zap.L(), payloadDecider))
-		stream = append(stream, grpc_zap.PayloadStreamServerInterceptor(zap.L(), payloadDecider))
-	}
+	// Named so its level can be adjusted separately, see LogLevels
+	logger := zap.L().Named("grpc")
+
+	// Tags first and request IDs after grpc_zap, so request IDs are added to the call's logger
+	unary = append(unary,
+		grpc_ctxtags.UnaryServerInterceptor(),
+		grpc_zap.UnaryServerInterceptor(logger, loggingOpts...),
+		RequestIDUnaryServerInterceptor(),
+	)
+	stream = append(stream,
+		grpc_ctxtags.StreamServerInterceptor(),
+		grpc_zap.StreamServerInterceptor(logger, loggingOpts...),
+		RequestIDStreamServerInterceptor(),
+	)
+
+	unary = append(unary, PayloadUnaryServerInterceptor(mode, payloads, redact))
+	stream = append(stream, PayloadStreamServerInterceptor(mode, payloads, redact))
 
 	return unary, stream
 }
@@ -1,16 +1,20 @@
 package october
 
 import (
+	"io"
 	"os"
-	"strconv"
 	"strings"
+	"sync"
 
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
 )
 
-func initService(mode Mode, fromEnv bool) (*OctoberServer, error) {
+func initService(cfg OctoberConfig, fromEnv bool) (*OctoberServer, error) {
 
-	loggerErr := ConfigureZap(mode)
+	mode := cfg.Mode
+
+	loggerErr := ConfigureZapWithConfig(mode, cfg.Log)
 	if loggerErr != nil {
 		return nil, loggerErr
 	}
@@ -24,32 +28,44 @@ func initService(mode Mode, fromEnv bool) (*OctoberServer, error) {
 		zap.L().Named("OCTOBER").Info("October Mode: " + mode.String())
 	}
 
-	port := 0
-	envPort := strings.TrimSpace(os.Getenv(portEnvVariable))
-	if envPort != "" {
-		var err error
-		port, err = strconv.Atoi(envPort)
-		if err != nil {
-			return nil, err
-		}
-	}
+	zap.L().Named("OCTOBER").Info("October config", ConfigZapFields(&cfg, octoberEnvPrefix)...)
 
-	server := NewOctoberServer(mode, port)
+	server := NewOctoberServerWithConfig(cfg)
 
 	return server, nil
 }
 
 func ConfigureZap(mode Mode) error {
-	logger, loggerErr := NewZapLogger(mode)
+	return ConfigureZapWithConfig(mode, DefaultOctoberConfig(mode).Log)
+}
+
+func ConfigureZapWithConfig(mode Mode, logConfig LogConfig) error {
+	built, loggerErr := buildZapLogger(mode, logConfig)
 	if loggerErr != nil {
 		return loggerErr
 	}
 
-	zap.ReplaceGlobals(logger)
+	zap.ReplaceGlobals(built.logger)
+	setGlobalLogLevels(built.levels)
+	setGlobalLogBuffer(built.buffer)
+	setRequestTraceProject(gcpTraceProjectFor(mode, logConfig))
+
+	// Sinks of the replaced logger would otherwise stay open for the life of the process
+	globalLogSinksLock.Lock()
+	previousSinks := globalLogSinks
+	globalLogSinks = built.sinks
+	globalLogSinksLock.Unlock()
+
+	closeLogSinks(previousSinks)
 
 	return nil
 }
 
+var (
+	globalLogSinksLock sync.Mutex
+	globalLogSinks     []io.Closer // Sinks of the logger installed by ConfigureZap
+)
+
 func MustConfigureZap(mode Mode) {
 	err := ConfigureZap(mode)
 	if err != nil {
@@ -57,9 +73,14 @@ func MustConfigureZap(mode Mode) {
 	}
 }
 
+// Configure zap from OCTOBER_MODE and OCTOBER_LOG_* environment variables
 func ConfigureZapFromEnv() error {
-	mode, _ := ModeFromEnv()
-	return ConfigureZap(mode)
+	cfg, err := OctoberConfigFromEnv()
+	if err != nil {
+		return err
+	}
+
+	return ConfigureZapWithConfig(cfg.Mode, cfg.Log)
 }
 
 func MustConfigureZapFromEnv() {
@@ -71,13 +92,125 @@ func MustConfigureZapFromEnv() {
 
 // InitService configures the following:
 // - Zap
+// Settings other than the mode are read from OCTOBER_* environment variables, see OctoberConfig
 func InitService(mode Mode) (*OctoberServer, error) {
-	return initService(mode, false)
+	cfg, err := OctoberConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.Mode = mode
+
+	return initService(cfg, false)
+}
+
+// Same as InitService, using cfg without reading any environment variables.
+// cfg is validated the same way as OctoberConfigFromEnv, start from DefaultOctoberConfig rather than an empty OctoberConfig.
+func InitServiceWithConfig(cfg OctoberConfig) (*OctoberServer, error) {
+	err := ValidateConfig(&cfg, octoberEnvPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return initService(cfg, false)
+}
+
+// Options for InitServiceFromEnv
+type InitOption func(*initOptions)
+
+type initOptions struct {
+	strictEnv   StrictEnvMode
+	dotEnvFiles []string
+	requireMode bool
+}
+
+// Refuse to start unless OCTOBER_MODE is set to a recognized mode.
+// An unset OCTOBER_MODE is refused too, even though ModeFromEnv treats it as LOCAL, so a deployment missing it never runs with the LOCAL policy.
+// Local development must set OCTOBER_MODE=LOCAL explicitly, e.g. in a .env file loaded with DotEnv.
+func RequireMode() InitOption {
+	return func(o *initOptions) {
+		o.requireMode = true
+	}
+}
+
+// Check for OCTOBER_* environment variables that don't match any October setting, e.g. OCTOBER_GRPC_PROT
+func StrictEnv(mode StrictEnvMode) InitOption {
+	return func(o *initOptions) {
+		o.strictEnv = mode
+	}
+}
+
+// Load dotenv files before reading the environment when OCTOBER_MODE is LOCAL or unset, see LoadDotEnvIfLocal.
+// Loads DefaultDotEnvFiles if no files are given.
+func DotEnv(files ...string) InitOption {
+	return func(o *initOptions) {
+		if len(files) == 0 {
+			files = DefaultDotEnvFiles
+		}
+		o.dotEnvFiles = files
+	}
 }
 
-func InitServiceFromEnv() (*OctoberServer, error) {
-	mode, found := ModeFromEnv()
-	return initService(mode, found)
+func InitServiceFromEnv(opts ...InitOption) (*OctoberServer, error) {
+	options := &initOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if len(options.dotEnvFiles) > 0 {
+		_, err := LoadDotEnvIfLocal(options.dotEnvFiles...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	envMode := strings.TrimSpace(os.Getenv(modeEnvVariable))
+	if options.requireMode {
+		if envMode == "" {
+			return nil, errors.Errorf("%s is required, set %s=LOCAL for local development", modeEnvVariable, modeEnvVariable)
+		}
+
+		_, err := ParseMode(envMode)
+		if err != nil {
+			return nil, errors.Wrap(err, modeEnvVariable)
+		}
+	}
+
+	cfg, err := OctoberConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	var unknown []UnknownEnvVariable
+	if options.strictEnv != StrictEnvOff {
+		known := knownConfigEnv(&cfg, octoberEnvPrefix)
+		known[configKeyEnvVariable] = true
+		known[configKeyFileEnvVariable] = true
+
+		unknown = findUnknownEnv(octoberEnvPrefix, known)
+	}
+
+	// Refused before initService replaces the global logger and opens log sinks
+	if options.strictEnv == StrictEnvError && len(unknown) > 0 {
+		return nil, &UnknownEnvError{Unknown: unknown}
+	}
+
+	_, found := ModeFromEnv()
+	server, err := initService(cfg, found)
+	if err != nil {
+		return nil, err
+	}
+
+	if envMode != "" && !found {
+		zap.L().Named("OCTOBER").Warn("Unrecognized " + modeEnvVariable + " " + envMode + ", running as " + cfg.Mode.String())
+	}
+
+	// Warned once zap is configured, so the warnings are logged
+	if options.strictEnv == StrictEnvWarn {
+		warnUnknownEnv(unknown)
+	}
+
+	return server, nil
 }
 
 func MustInitService(mode Mode) *OctoberServer {
@@ -89,8 +222,17 @@ func MustInitService(mode Mode) *OctoberServer {
 	return server
 }
 
-func MustInitServiceFromEnv() *OctoberServer {
-	server, err := InitServiceFromEnv()
+func MustInitServiceWithConfig(cfg OctoberConfig) *OctoberServer {
+	server, err := InitServiceWithConfig(cfg)
+	if err != nil {
+		panic(err)
+	}
+
+	return server
+}
+
+func MustInitServiceFromEnv(opts ...InitOption) *OctoberServer {
+	server, err := InitServiceFromEnv(opts...)
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,77 @@
+package october
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// Restores the global logger, levels, buffer and sinks replaced by initService once the test ends
+func restoreInitServiceGlobals(t *testing.T) {
+	previous := zap.L()
+	previousLevels := GlobalLogLevels()
+
+	t.Cleanup(func() {
+		zap.ReplaceGlobals(previous)
+		setGlobalLogLevels(previousLevels)
+		setGlobalLogBuffer(nil)
+		globalLogSinksLock.Lock()
+		closeLogSinks(globalLogSinks)
+		globalLogSinks = nil
+		globalLogSinksLock.Unlock()
+	})
+}
+
+func TestInitServiceFromEnvRequireMode(t *testing.T) {
+	restoreInitServiceGlobals(t)
+	t.Setenv("OCTOBER_LOG_OUTPUT_PATHS", filepath.Join(t.TempDir(), "app.log"))
+
+	tests := []struct {
+		name    string
+		mode    string
+		dotEnv  string
+		wantErr string
+	}{
+		{"unset", "", "", "OCTOBER_MODE is required"},
+		{"unrecognized", "PRODUCTION", "", `Unrecognized mode "PRODUCTION"`},
+		{"recognized", "prod", "", ""},
+		{"LOCAL", "LOCAL", "", ""},
+		{"LOCAL from dotenv", "", "OCTOBER_MODE=LOCAL\n", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv(modeEnvVariable, test.mode)
+			if test.mode == "" {
+				os.Unsetenv(modeEnvVariable)
+			}
+
+			opts := []InitOption{RequireMode()}
+			if test.dotEnv != "" {
+				path := filepath.Join(t.TempDir(), ".env")
+				writeTestFile(t, path, test.dotEnv)
+				cleanupDotEnv(t, modeEnvVariable)
+				opts = append(opts, DotEnv(path))
+			}
+
+			previous := zap.L()
+			_, err := InitServiceFromEnv(opts...)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("got error %v, want %q", err, test.wantErr)
+			}
+			if zap.L() != previous {
+				t.Error("global logger replaced before the mode was refused")
+			}
+		})
+	}
+}
@@ -0,0 +1,256 @@
+package october
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// A log entry retained by LogBuffer
+type LogEntry struct {
+	Time    time.Time              `json:"time"`
+	Level   zapcore.Level          `json:"level"`
+	Logger  string                 `json:"logger,omitempty"`
+	Message string                 `json:"msg"`
+	Caller  string                 `json:"caller,omitempty"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+	Stack   string                 `json:"stacktrace,omitempty"`
+}
+
+// Selects log entries, zero values match everything
+type LogFilter struct {
+	Level  zapcore.Level // Minimum level
+	Logger string        // Logger name, also matching its children, e.g. OCTOBER matches OCTOBER.grpc
+	Text   string        // Case insensitive text found in the message or fields
+}
+
+func (f LogFilter) Match(entry LogEntry) bool {
+	if entry.Level < f.Level {
+		return false
+	}
+
+	if f.Logger != "" && entry.Logger != f.Logger && !strings.HasPrefix(entry.Logger, f.Logger+".") {
+		return false
+	}
+
+	if f.Text != "" {
+		text := strings.ToLower(f.Text)
+		if strings.Contains(strings.ToLower(entry.Message), text) {
+			return true
+		}
+
+		if len(entry.Fields) == 0 {
+			return false
+		}
+
+		fields, err := json.Marshal(entry.Fields)
+		if err != nil || !strings.Contains(strings.ToLower(string(fields)), text) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Retains the most recent log entries in memory, for serving on /debug/logs.
+// Entries are written by the core returned by Core, and can be followed as they're written with Subscribe.
+type LogBuffer struct {
+	lock    sync.RWMutex
+	entries []LogEntry
+	next    int  // Index the next entry is written to
+	full    bool // True once entries has wrapped around
+
+	subscribers map[chan LogEntry]struct{}
+}
+
+// Buffered entries per subscriber, entries are dropped for subscribers that fall further behind
+const logBufferSubscriberBuffer = 256
+
+func NewLogBuffer(capacity int) *LogBuffer {
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	return &LogBuffer{
+		entries:     make([]LogEntry, capacity),
+		subscribers: make(map[chan LogEntry]struct{}),
+	}
+}
+
+// Returns a core writing enabled entries into this buffer
+func (b *LogBuffer) Core(enabler zapcore.LevelEnabler) zapcore.Core {
+	return &logBufferCore{LevelEnabler: enabler, buffer: b}
+}
+
+// Returns buffered entries matching filter, oldest first. If limit is positive only the newest limit entries are returned.
+func (b *LogBuffer) Entries(filter LogFilter, limit int) []LogEntry {
+	// Copied so logging isn't blocked while filtering
+	b.lock.RLock()
+	entries := b.ordered()
+	b.lock.RUnlock()
+
+	var matched []LogEntry
+	for _, entry := range entries {
+		if filter.Match(entry) {
+			matched = append(matched, entry)
+		}
+	}
+
+	if limit > 0 && len(matched) > limit {
+		matched = matched[len(matched)-limit:]
+	}
+
+	return matched
+}
+
+// Returns a channel receiving entries as they're written, and a function ending the subscription.
+// Entries are dropped rather than blocking logging if the receiver falls behind.
+func (b *LogBuffer) Subscribe() (<-chan LogEntry, func()) {
+	ch := make(chan LogEntry, logBufferSubscriberBuffer)
+
+	b.lock.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.lock.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.lock.Lock()
+			delete(b.subscribers, ch)
+			b.lock.Unlock()
+		})
+	}
+
+	return ch, unsubscribe
+}
+
+func (b *LogBuffer) add(entry LogEntry) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.entries[b.next] = entry
+	b.next++
+	if b.next == len(b.entries) {
+		b.next = 0
+		b.full = true
+	}
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- entry:
+		default:
+		}
+	}
+}
+
+// Returns a copy of the entries, oldest first. Must hold lock.
+func (b *LogBuffer) ordered() []LogEntry {
+	if !b.full {
+		return append([]LogEntry(nil), b.entries[:b.next]...)
+	}
+
+	ordered := make([]LogEntry, 0, len(b.entries))
+	ordered = append(ordered, b.entries[b.next:]...)
+	ordered = append(ordered, b.entries[:b.next]...)
+
+	return ordered
+}
+
+type logBufferCore struct {
+	zapcore.LevelEnabler
+	buffer *LogBuffer
+	fields []zapcore.Field
+}
+
+func (c *logBufferCore) With(fields []zapcore.Field) zapcore.Core {
+	return &logBufferCore{
+		LevelEnabler: c.LevelEnabler,
+		buffer:       c.buffer,
+		fields:       append(c.fields[:len(c.fields):len(c.fields)], fields...),
+	}
+}
+
+func (c *logBufferCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+
+	return checked
+}
+
+func (c *logBufferCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	logEntry := LogEntry{
+		Time:    entry.Time,
+		Level:   entry.Level,
+		Logger:  entry.LoggerName,
+		Message: entry.Message,
+		Stack:   entry.Stack,
+	}
+
+	if entry.Caller.Defined {
+		logEntry.Caller = entry.Caller.TrimmedPath()
+	}
+
+	if len(c.fields) > 0 || len(fields) > 0 {
+		var err error
+		logEntry.Fields, err = snapshotLogFields(append(c.fields[:len(c.fields):len(c.fields)], fields...))
+		if err != nil {
+			return err
+		}
+	}
+
+	c.buffer.add(logEntry)
+
+	return nil
+}
+
+// Encoder for the fields of buffered entries, writing only the fields
+var logBufferFieldEncoder = zapcore.NewJSONEncoder(zapcore.EncoderConfig{
+	EncodeTime:     zapcore.RFC3339NanoTimeEncoder,
+	EncodeDuration: zapcore.StringDurationEncoder,
+})
+
+// Returns the values of fields as they are now. Fields may refer to values the caller goes on to change, such as
+// maps logged with zap.Any, so they're encoded while the entry is written rather than when it's read.
+func snapshotLogFields(fields []zapcore.Field) (map[string]interface{}, error) {
+	buf, err := logBufferFieldEncoder.EncodeEntry(zapcore.Entry{}, fields)
+	if err != nil {
+		return nil, err
+	}
+	defer buf.Free()
+
+	var snapshot map[string]interface{}
+	err = json.Unmarshal(buf.Bytes(), &snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+func (c *logBufferCore) Sync() error {
+	return nil
+}
+
+var (
+	globalLogBufferLock sync.RWMutex
+	globalLogBuffer     *LogBuffer
+)
+
+// Returns the buffer of the logger installed by ConfigureZap, nil if it hasn't been called or the buffer is disabled, see LogConfig.Buffer
+func GlobalLogBuffer() *LogBuffer {
+	globalLogBufferLock.RLock()
+	defer globalLogBufferLock.RUnlock()
+
+	return globalLogBuffer
+}
+
+func setGlobalLogBuffer(buffer *LogBuffer) {
+	globalLogBufferLock.Lock()
+	defer globalLogBufferLock.Unlock()
+
+	globalLogBuffer = buffer
+}
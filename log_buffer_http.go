@@ -0,0 +1,139 @@
+package october
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap/zapcore"
+)
+
+// Entries returned when no limit is given
+const defaultLogBufferLimit = 100
+
+// How often an idle tail sends a comment, keeping proxies from closing the connection
+const logTailKeepAlive = 15 * time.Second
+
+// Serves entries retained by the global log buffer, see LogConfig.Buffer.
+// Filtered by ?level= (minimum level), ?logger= (name and children) and ?q= (text), limited to the newest ?limit= entries.
+// With ?follow=true, or when accepting text/event-stream, matching entries are streamed as server-sent events as they're logged,
+// until the client disconnects or done is closed.
+func logBufferHTTPHandler(done <-chan struct{}) func(http.ResponseWriter, *http.Request) {
+
+	return func(write http.ResponseWriter, req *http.Request) {
+		buffer := GlobalLogBuffer()
+		if buffer == nil {
+			writeLogLevelError(write, http.StatusServiceUnavailable, errors.New("log buffer is disabled, or zap has not been configured by October"))
+			return
+		}
+
+		if req.Method != http.MethodGet {
+			write.Header().Set("Allow", "GET")
+			writeLogLevelError(write, http.StatusMethodNotAllowed, errors.Errorf("method %s not allowed", req.Method))
+			return
+		}
+
+		filter, limit, err := logFilterFromRequest(req)
+		if err != nil {
+			writeLogLevelError(write, http.StatusBadRequest, err)
+			return
+		}
+
+		follow, _ := strconv.ParseBool(req.URL.Query().Get("follow"))
+		if follow || strings.Contains(req.Header.Get("Accept"), "text/event-stream") {
+			tailLogBuffer(write, req, done, buffer, filter, limit)
+			return
+		}
+
+		entries := buffer.Entries(filter, limit)
+		if entries == nil {
+			entries = []LogEntry{}
+		}
+
+		writeLogLevelJSON(write, http.StatusOK, entries)
+	}
+}
+
+func logFilterFromRequest(req *http.Request) (LogFilter, int, error) {
+	query := req.URL.Query()
+
+	filter := LogFilter{
+		Level:  zapcore.DebugLevel,
+		Logger: query.Get("logger"),
+		Text:   query.Get("q"),
+	}
+
+	if level := query.Get("level"); level != "" {
+		err := filter.Level.UnmarshalText([]byte(level))
+		if err != nil {
+			return filter, 0, err
+		}
+	}
+
+	limit := defaultLogBufferLimit
+	if rawLimit := query.Get("limit"); rawLimit != "" {
+		var err error
+		limit, err = strconv.Atoi(rawLimit)
+		if err != nil || limit < 0 {
+			return filter, 0, errors.Errorf("invalid limit %s", rawLimit)
+		}
+	}
+
+	return filter, limit, nil
+}
+
+// Streams matching buffered entries, then new entries, until the client disconnects or done is closed
+func tailLogBuffer(write http.ResponseWriter, req *http.Request, done <-chan struct{}, buffer *LogBuffer, filter LogFilter, limit int) {
+	flusher, ok := write.(http.Flusher)
+	if !ok {
+		writeLogLevelError(write, http.StatusInternalServerError, errors.New("streaming is not supported"))
+		return
+	}
+
+	// Subscribed before reading the backlog, entries logged in between may be sent twice but aren't lost
+	entries, unsubscribe := buffer.Subscribe()
+	defer unsubscribe()
+
+	write.Header().Set("Content-Type", "text/event-stream")
+	write.Header().Set("Cache-Control", "no-cache")
+	write.WriteHeader(http.StatusOK)
+
+	for _, entry := range buffer.Entries(filter, limit) {
+		writeLogEvent(write, entry)
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(logTailKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case <-done:
+			return
+		case <-keepAlive.C:
+			write.Write([]byte(": keep-alive\n\n"))
+			flusher.Flush()
+		case entry := <-entries:
+			if filter.Match(entry) {
+				writeLogEvent(write, entry)
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+func writeLogEvent(write http.ResponseWriter, entry LogEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	write.Write([]byte("data: "))
+	write.Write(data)
+	write.Write([]byte("\n\n"))
+}
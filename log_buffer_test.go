@@ -0,0 +1,147 @@
+package october
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLogBufferEntries(t *testing.T) {
+	buffer := NewLogBuffer(3)
+	logger := zap.New(buffer.Core(zapcore.DebugLevel))
+
+	logger.Debug("dropped by capacity")
+	logger.Named("OCTOBER").Info("starting")
+	logger.Named("OCTOBER.grpc").Warn("slow call", zap.String("method", "/api.Users/Get"))
+	logger.Named("app").Error("failed")
+
+	tests := []struct {
+		name   string
+		filter LogFilter
+		limit  int
+		want   []string
+	}{
+		{"all", LogFilter{}, 0, []string{"starting", "slow call", "failed"}},
+		{"limit", LogFilter{}, 2, []string{"slow call", "failed"}},
+		{"level", LogFilter{Level: zapcore.WarnLevel}, 0, []string{"slow call", "failed"}},
+		{"logger and children", LogFilter{Logger: "OCTOBER"}, 0, []string{"starting", "slow call"}},
+		{"text in fields", LogFilter{Text: "USERS"}, 0, []string{"slow call"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entries := buffer.Entries(test.filter, test.limit)
+			if len(entries) != len(test.want) {
+				t.Fatalf("got %d entries, want %v", len(entries), test.want)
+			}
+			for i, entry := range entries {
+				if entry.Message != test.want[i] {
+					t.Errorf("entry %d is %q, want %q", i, entry.Message, test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLogBufferSnapshotsFields(t *testing.T) {
+	buffer := NewLogBuffer(10)
+	logger := zap.New(buffer.Core(zapcore.DebugLevel)).With(zap.Duration("timeout", time.Second))
+
+	values := map[string]int{"count": 1}
+	logger.Info("logged", zap.Any("values", values), zap.Time("at", time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC)))
+	values["count"] = 2
+
+	fields := buffer.Entries(LogFilter{}, 0)[0].Fields
+	if count := fields["values"].(map[string]interface{})["count"]; count != float64(1) {
+		t.Errorf("buffered value changed with the logged map, got count %v", count)
+	}
+	if fields["timeout"] != "1s" || fields["at"] != "2020-01-02T15:04:05Z" {
+		t.Errorf("got fields %v", fields)
+	}
+}
+
+func TestLogBufferModeDefault(t *testing.T) {
+	size := func(n int) *int { return &n }
+
+	tests := []struct {
+		name   string
+		mode   Mode
+		buffer *int
+		want   bool
+	}{
+		{"local", LOCAL, nil, true},
+		{"dev", DEV, nil, true},
+		{"stage", STAGE, nil, false},
+		{"prod", PROD, nil, false},
+		{"enabled in prod", PROD, size(10), true},
+		{"disabled locally", LOCAL, size(0), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			built, err := buildZapLogger(test.mode, LogConfig{Buffer: test.buffer, OutputPaths: []string{"stdout"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := built.buffer != nil; got != test.want {
+				t.Errorf("got buffer %t, want %t", got, test.want)
+			}
+		})
+	}
+}
+
+func TestLogBufferEndpointNeedsDebugEndpoints(t *testing.T) {
+	for _, mode := range []Mode{LOCAL, DEV, STAGE, PROD} {
+		mux := (&OctoberServer{mode: mode, server: &http.Server{}}).buildServerMux()
+		_, pattern := mux.Handler(httptest.NewRequest(http.MethodGet, "/debug/logs", nil))
+
+		if served := pattern == "/debug/logs"; served != mode.Policy().DebugEndpoints {
+			t.Errorf("%s: /debug/logs served %t, debug endpoints %t", mode, served, mode.Policy().DebugEndpoints)
+		}
+	}
+}
+
+func TestLogBufferTailEndsOnShutdown(t *testing.T) {
+	setGlobalLogBuffer(NewLogBuffer(10))
+	defer setGlobalLogBuffer(nil)
+
+	o := &OctoberServer{mode: LOCAL, server: &http.Server{}}
+	o.server.Handler = o.buildServerMux()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go o.server.Serve(lis)
+
+	resp, err := http.Get("http://" + lis.Addr().String() + "/debug/logs?follow=true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got status %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	err = o.server.Shutdown(ctx)
+	if err != nil {
+		t.Fatalf("shutdown with an open tail: %v", err)
+	}
+
+	_, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Errorf("tail not ended cleanly: %v", err)
+	}
+}
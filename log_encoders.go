@@ -0,0 +1,313 @@
+package october
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Log encodings, selected with LogConfig.Encoding or ModePolicy.LogEncoding
+const (
+	LogEncodingJSON    = "json"
+	LogEncodingConsole = "console"
+	LogEncodingLogfmt  = "logfmt"
+	LogEncodingECS     = "ecs" // Elastic Common Schema JSON
+	LogEncodingGCP     = "gcp" // Google Cloud Logging structured JSON
+)
+
+// Version of Elastic Common Schema written by the ecs encoding
+const ecsVersion = "1.6.0"
+
+// Special field linking an entry to a Cloud Trace trace, see GCPTraceFields
+const (
+	GCPTraceKey        = "logging.googleapis.com/trace"
+	GCPSpanIDKey       = "logging.googleapis.com/spanId"
+	GCPTraceSampledKey = "logging.googleapis.com/trace_sampled"
+)
+
+// Request headers, and GRPC metadata keys, carrying the trace of a request
+const (
+	GCPTraceContextHeader = "X-Cloud-Trace-Context"
+	TraceparentHeader     = "traceparent" // W3C Trace Context
+)
+
+// Environment variable naming the Google Cloud project, set on most Google Cloud runtimes
+const gcpProjectEnvVariable = "GOOGLE_CLOUD_PROJECT"
+
+func init() {
+	for _, encoding := range []string{LogEncodingLogfmt, LogEncodingECS, LogEncodingGCP} {
+		encoding := encoding
+
+		// Registered so zap.Config can refer to them by name
+		err := zap.RegisterEncoder(encoding, func(encoderConfig zapcore.EncoderConfig) (zapcore.Encoder, error) {
+			return newLogEncoder(encoding, encoderConfig)
+		})
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Returns the encoder config for encoding, with LogConfig's time format and keys applied
+func logEncoderConfig(encoding string, logConfig LogConfig) (zapcore.EncoderConfig, error) {
+	encoderConfig := zapcore.EncoderConfig{
+		TimeKey:        "ts",
+		LevelKey:       "level",
+		NameKey:        "logger",
+		CallerKey:      "caller",
+		MessageKey:     "msg",
+		StacktraceKey:  "stacktrace",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeDuration: zapcore.SecondsDurationEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+	}
+
+	switch encoding {
+	case LogEncodingConsole:
+		// Console output is read by people
+		encoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	case LogEncodingJSON:
+		encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+		encoderConfig.EncodeTime = zapcore.EpochMillisTimeEncoder
+	case LogEncodingLogfmt:
+		encoderConfig.EncodeLevel = zapcore.LowercaseLevelEncoder
+		encoderConfig.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+		encoderConfig.EncodeDuration = zapcore.StringDurationEncoder
+	case LogEncodingECS:
+		encoderConfig.TimeKey = "@timestamp"
+		encoderConfig.LevelKey = "log.level"
+		encoderConfig.NameKey = "log.logger"
+		encoderConfig.CallerKey = "log.origin"
+		encoderConfig.MessageKey = "message"
+		encoderConfig.StacktraceKey = "error.stack_trace"
+		encoderConfig.EncodeLevel = zapcore.LowercaseLevelEncoder
+		encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+		encoderConfig.EncodeDuration = zapcore.NanosDurationEncoder
+	case LogEncodingGCP:
+		encoderConfig.TimeKey = "time"
+		encoderConfig.LevelKey = "severity"
+		encoderConfig.CallerKey = "logging.googleapis.com/sourceLocation"
+		encoderConfig.MessageKey = "message"
+		encoderConfig.EncodeLevel = gcpSeverityEncoder
+		encoderConfig.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+	default:
+		return encoderConfig, errors.Errorf("unsupported log encoding %s, must be one of json, console, logfmt, ecs or gcp", encoding)
+	}
+
+	if logConfig.TimeFormat != "" {
+		encoderConfig.EncodeTime = zapTimeEncoder(logConfig.TimeFormat)
+	}
+
+	err := applyLogKeys(&encoderConfig, logConfig.Keys)
+	if err != nil {
+		return encoderConfig, err
+	}
+
+	return encoderConfig, nil
+}
+
+// Renames the keys of entry values, by role. An empty key or "-" omits the value.
+// Roles are time, level, logger, caller, function, message and stacktrace.
+func applyLogKeys(encoderConfig *zapcore.EncoderConfig, keys map[string]string) error {
+	roles := map[string]*string{
+		"time":       &encoderConfig.TimeKey,
+		"level":      &encoderConfig.LevelKey,
+		"logger":     &encoderConfig.NameKey,
+		"caller":     &encoderConfig.CallerKey,
+		"function":   &encoderConfig.FunctionKey,
+		"message":    &encoderConfig.MessageKey,
+		"stacktrace": &encoderConfig.StacktraceKey,
+	}
+
+	var unknown []string
+	for role, key := range keys {
+		target, ok := roles[strings.ToLower(strings.TrimSpace(role))]
+		if !ok {
+			unknown = append(unknown, role)
+			continue
+		}
+
+		key = strings.TrimSpace(key)
+		if key == "-" {
+			key = ""
+		}
+		*target = key
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return errors.Errorf("unknown log keys %s, must be time, level, logger, caller, function, message or stacktrace", strings.Join(unknown, ", "))
+	}
+
+	return nil
+}
+
+// Builds an encoder by encoding name
+func newLogEncoder(encoding string, encoderConfig zapcore.EncoderConfig) (zapcore.Encoder, error) {
+	switch encoding {
+	case LogEncodingJSON:
+		return zapcore.NewJSONEncoder(encoderConfig), nil
+	case LogEncodingConsole:
+		return zapcore.NewConsoleEncoder(encoderConfig), nil
+	case LogEncodingLogfmt:
+		return newLogfmtEncoder(encoderConfig), nil
+	case LogEncodingECS:
+		encoderConfig.EncodeCaller = objectCallerEncoder(ecsOrigin)
+		encoder := zapcore.NewJSONEncoder(encoderConfig)
+		encoder.AddString("ecs.version", ecsVersion)
+		return encoder, nil
+	case LogEncodingGCP:
+		encoderConfig.EncodeCaller = objectCallerEncoder(gcpSourceLocation)
+		return zapcore.NewJSONEncoder(encoderConfig), nil
+	}
+
+	return nil, errors.Errorf("unsupported log encoding %s", encoding)
+}
+
+// Returns a caller encoder writing the caller as an object, as ECS and Cloud Logging expect.
+// zap's JSON encoder appends the caller to itself, so the object is written in the caller's place among the entry keys,
+// outside any namespace opened by fields. Other encoders get the caller as a string.
+func objectCallerEncoder(marshal func(zapcore.EntryCaller) zapcore.ObjectMarshaler) zapcore.CallerEncoder {
+	return func(caller zapcore.EntryCaller, enc zapcore.PrimitiveArrayEncoder) {
+		if objects, ok := enc.(zapcore.ArrayEncoder); ok {
+			err := objects.AppendObject(marshal(caller))
+			if err == nil {
+				return
+			}
+		}
+
+		enc.AppendString(caller.TrimmedPath())
+	}
+}
+
+func ecsOrigin(caller zapcore.EntryCaller) zapcore.ObjectMarshaler {
+	return zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+		file := caller.TrimmedPath()
+		if colon := strings.LastIndex(file, ":"); colon >= 0 {
+			file = file[:colon]
+		}
+
+		enc.AddString("file.name", file)
+		enc.AddInt("file.line", caller.Line)
+		if caller.Function != "" {
+			enc.AddString("function", caller.Function)
+		}
+		return nil
+	})
+}
+
+func gcpSourceLocation(caller zapcore.EntryCaller) zapcore.ObjectMarshaler {
+	return zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+		enc.AddString("file", caller.File)
+		enc.AddString("line", fmt.Sprint(caller.Line))
+		if caller.Function != "" {
+			enc.AddString("function", caller.Function)
+		}
+		return nil
+	})
+}
+
+// Cloud Logging LogSeverity names
+func gcpSeverityEncoder(level zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	switch level {
+	case zapcore.DebugLevel:
+		enc.AppendString("DEBUG")
+	case zapcore.InfoLevel:
+		enc.AppendString("INFO")
+	case zapcore.WarnLevel:
+		enc.AppendString("WARNING")
+	case zapcore.ErrorLevel:
+		enc.AppendString("ERROR")
+	case zapcore.DPanicLevel:
+		enc.AppendString("CRITICAL")
+	case zapcore.PanicLevel:
+		enc.AppendString("ALERT")
+	case zapcore.FatalLevel:
+		enc.AppendString("EMERGENCY")
+	default:
+		enc.AppendString("DEFAULT")
+	}
+}
+
+// Fields linking entries to a Cloud Trace trace, from an X-Cloud-Trace-Context header value, e.g. TRACE_ID/SPAN_ID;o=1.
+// Returns no fields if traceContext is empty.
+func GCPTraceFields(projectID, traceContext string) []zap.Field {
+	traceContext = strings.TrimSpace(traceContext)
+	if traceContext == "" {
+		return nil
+	}
+
+	options := ""
+	if semi := strings.Index(traceContext, ";"); semi >= 0 {
+		traceContext, options = traceContext[:semi], traceContext[semi+1:]
+	}
+
+	traceID, spanID := traceContext, ""
+	if slash := strings.Index(traceContext, "/"); slash >= 0 {
+		traceID, spanID = traceContext[:slash], traceContext[slash+1:]
+	}
+
+	fields := []zap.Field{
+		zap.String(GCPTraceKey, "projects/"+projectID+"/traces/"+traceID),
+	}
+
+	// The header has a decimal span ID, Cloud Logging expects hex
+	if span, err := strconv.ParseUint(spanID, 10, 64); err == nil {
+		fields = append(fields, zap.String(GCPSpanIDKey, fmt.Sprintf("%016x", span)))
+	}
+
+	if options != "" {
+		fields = append(fields, zap.Bool(GCPTraceSampledKey, options == "o=1"))
+	}
+
+	return fields
+}
+
+// Fields linking entries to a Cloud Trace trace, from a W3C traceparent header value, e.g. 00-TRACE_ID-SPAN_ID-01.
+// Returns no fields if traceparent is empty or malformed.
+func GCPTraceparentFields(projectID, traceparent string) []zap.Field {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return nil
+	}
+
+	for _, part := range parts[:4] {
+		if _, err := hex.DecodeString(part); err != nil {
+			return nil
+		}
+	}
+
+	flags, _ := strconv.ParseUint(parts[3], 16, 8)
+
+	return []zap.Field{
+		zap.String(GCPTraceKey, "projects/"+projectID+"/traces/"+strings.ToLower(parts[1])),
+		zap.String(GCPSpanIDKey, strings.ToLower(parts[2])),
+		zap.Bool(GCPTraceSampledKey, flags&1 == 1),
+	}
+}
+
+// Returns the project request logs are linked to Cloud Trace traces in, empty if they aren't
+func gcpTraceProjectFor(mode Mode, logConfig LogConfig) string {
+	if logConfig.GCPProject != "" {
+		return logConfig.GCPProject
+	}
+
+	encoding := strings.ToLower(logConfig.Encoding)
+	if encoding == "" {
+		encoding = mode.Policy().LogEncoding
+	}
+
+	if encoding != LogEncodingGCP {
+		return ""
+	}
+
+	return strings.TrimSpace(os.Getenv(gcpProjectEnvVariable))
+}
@@ -0,0 +1,334 @@
+package october
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc/metadata"
+)
+
+func testLogEntry() zapcore.Entry {
+	return zapcore.Entry{
+		Level:      zapcore.WarnLevel,
+		Time:       time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC),
+		LoggerName: "app",
+		Message:    "hello world",
+		Caller:     zapcore.NewEntryCaller(0, "/src/github.com/willtrking/october/server.go", 42, true),
+	}
+}
+
+func TestLogEncoders(t *testing.T) {
+	fields := []zapcore.Field{zap.String("user", "ada"), zap.Namespace("http"), zap.Int("status", 200)}
+
+	tests := []struct {
+		encoding string
+		want     map[string]interface{} // Decoded JSON values, by key
+	}{
+		{
+			encoding: LogEncodingECS,
+			want: map[string]interface{}{
+				"@timestamp":  "2020-01-02T15:04:05.000Z",
+				"log.level":   "warn",
+				"log.logger":  "app",
+				"message":     "hello world",
+				"ecs.version": ecsVersion,
+				"log.origin":  map[string]interface{}{"file.name": "october/server.go", "file.line": float64(42)},
+				"user":        "ada",
+				"http":        map[string]interface{}{"status": float64(200)},
+			},
+		},
+		{
+			encoding: LogEncodingGCP,
+			want: map[string]interface{}{
+				"time":     "2020-01-02T15:04:05Z",
+				"severity": "WARNING",
+				"logger":   "app",
+				"message":  "hello world",
+				"logging.googleapis.com/sourceLocation": map[string]interface{}{
+					"file": "/src/github.com/willtrking/october/server.go",
+					"line": "42",
+				},
+				"user": "ada",
+				"http": map[string]interface{}{"status": float64(200)},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.encoding, func(t *testing.T) {
+			encoderConfig, err := logEncoderConfig(test.encoding, LogConfig{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			encoder, err := newLogEncoder(test.encoding, encoderConfig)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			buf, err := encoder.EncodeEntry(testLogEntry(), fields)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got map[string]interface{}
+			err = json.Unmarshal(buf.Bytes(), &got)
+			if err != nil {
+				t.Fatalf("invalid JSON %s: %v", buf.String(), err)
+			}
+
+			if len(got) != len(test.want) {
+				t.Errorf("got %d keys, want %d: %s", len(got), len(test.want), buf.String())
+			}
+			for key, want := range test.want {
+				wantJSON, _ := json.Marshal(want)
+				gotJSON, _ := json.Marshal(got[key])
+				if string(gotJSON) != string(wantJSON) {
+					t.Errorf("%s is %s, want %s", key, gotJSON, wantJSON)
+				}
+			}
+		})
+	}
+}
+
+func TestLogfmtEncoder(t *testing.T) {
+	encoderConfig, err := logEncoderConfig(LogEncodingLogfmt, LogConfig{Keys: map[string]string{"caller": "-"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoder, err := newLogEncoder(LogEncodingLogfmt, encoderConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoder.AddString("service", "api")
+
+	buf, err := encoder.EncodeEntry(testLogEntry(), []zapcore.Field{
+		zap.String("quoted", `say "hi"`),
+		zap.Duration("elapsed", 1500*time.Millisecond),
+		zap.Namespace("http"),
+		zap.Int("status", 200),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `ts=2020-01-02T15:04:05Z level=warn logger=app msg="hello world" elapsed=1.5s http.status=200 quoted="say \"hi\"" service=api` + "\n"
+	if buf.String() != want {
+		t.Errorf("got  %s\nwant %s", buf.String(), want)
+	}
+}
+
+func TestGCPTraceFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields []zap.Field
+		want   map[string]interface{}
+	}{
+		{
+			name:   "cloud trace context",
+			fields: GCPTraceFields("proj", "105445aa7843bc8bf206b12000100000/1;o=1"),
+			want: map[string]interface{}{
+				GCPTraceKey:        "projects/proj/traces/105445aa7843bc8bf206b12000100000",
+				GCPSpanIDKey:       "0000000000000001",
+				GCPTraceSampledKey: true,
+			},
+		},
+		{
+			name:   "cloud trace context without span",
+			fields: GCPTraceFields("proj", "105445aa7843bc8bf206b12000100000"),
+			want:   map[string]interface{}{GCPTraceKey: "projects/proj/traces/105445aa7843bc8bf206b12000100000"},
+		},
+		{
+			name:   "empty cloud trace context",
+			fields: GCPTraceFields("proj", " "),
+			want:   map[string]interface{}{},
+		},
+		{
+			name:   "traceparent",
+			fields: GCPTraceparentFields("proj", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-00"),
+			want: map[string]interface{}{
+				GCPTraceKey:        "projects/proj/traces/4bf92f3577b34da6a3ce929d0e0e4736",
+				GCPSpanIDKey:       "00f067aa0ba902b7",
+				GCPTraceSampledKey: false,
+			},
+		},
+		{
+			name:   "malformed traceparent",
+			fields: GCPTraceparentFields("proj", "00-4bf92f3577b34da6a3ce929d0e0e4736-zzzzzzzzzzzzzzzz-01"),
+			want:   map[string]interface{}{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			enc := zapcore.NewMapObjectEncoder()
+			for _, field := range test.fields {
+				field.AddTo(enc)
+			}
+
+			if len(enc.Fields) != len(test.want) {
+				t.Errorf("got fields %v, want %v", enc.Fields, test.want)
+			}
+			for key, want := range test.want {
+				if got := enc.Fields[key]; got != want {
+					t.Errorf("%s is %v, want %v", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGinRequestTraceFields(t *testing.T) {
+	defer setRequestTraceProject("")
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		project string
+		headers map[string]string
+		want    string // Trace field, empty for none
+	}{
+		{
+			name:    "not linked",
+			headers: map[string]string{GCPTraceContextHeader: "abc/1;o=1"},
+		},
+		{
+			name:    "cloud trace context",
+			project: "proj",
+			headers: map[string]string{GCPTraceContextHeader: "abc/1;o=1", TraceparentHeader: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+			want:    "projects/proj/traces/abc",
+		},
+		{
+			name:    "traceparent",
+			project: "proj",
+			headers: map[string]string{TraceparentHeader: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+			want:    "projects/proj/traces/4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+		{
+			name:    "no trace",
+			project: "proj",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setRequestTraceProject(test.project)
+
+			core, logs := observer.New(zapcore.DebugLevel)
+			router := gin.New()
+			router.Use(GinRequestID(zap.New(core)))
+			router.GET("/", func(c *gin.Context) {
+				Logger(c.Request.Context()).Info("handled")
+			})
+
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			for key, value := range test.headers {
+				request.Header.Set(key, value)
+			}
+			router.ServeHTTP(httptest.NewRecorder(), request)
+
+			entries := logs.All()
+			if len(entries) != 1 {
+				t.Fatalf("got %d entries, want 1", len(entries))
+			}
+
+			fields := entries[0].ContextMap()
+			if fields[RequestIDField] == "" {
+				t.Error("request ID missing")
+			}
+
+			got, _ := fields[GCPTraceKey].(string)
+			if got != test.want {
+				t.Errorf("trace is %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGCPTraceProjectFor(t *testing.T) {
+	t.Setenv(gcpProjectEnvVariable, "env-proj")
+
+	tests := []struct {
+		name      string
+		logConfig LogConfig
+		want      string
+	}{
+		{"configured", LogConfig{GCPProject: "proj", Encoding: LogEncodingJSON}, "proj"},
+		{"gcp encoding", LogConfig{Encoding: strings.ToUpper(LogEncodingGCP)}, "env-proj"},
+		{"other encoding", LogConfig{Encoding: LogEncodingJSON}, ""},
+	}
+
+	for _, test := range tests {
+		if got := gcpTraceProjectFor(PROD, test.logConfig); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestGRPCRequestTraceFields(t *testing.T) {
+	defer setRequestTraceProject("")
+	setRequestTraceProject("proj")
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"))
+	ctx = grpc_ctxtags.SetInContext(ctx, grpc_ctxtags.NewTags())
+	ctx = ctxzap.ToContext(ctx, zap.New(core))
+
+	ctx = grpcRequestContext(ctx)
+	Logger(ctx).Info("handled")
+	ctxzap.Extract(ctx).Info("finished call")
+
+	for _, entry := range logs.All() {
+		if got := entry.ContextMap()[GCPTraceKey]; got != "projects/proj/traces/4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("%s: trace is %v", entry.Message, got)
+		}
+	}
+	if logs.Len() != 2 {
+		t.Errorf("got %d entries, want 2", logs.Len())
+	}
+}
+
+func TestLogfmtEncoderCloneNamespace(t *testing.T) {
+	encoderConfig, err := logEncoderConfig(LogEncodingLogfmt, LogConfig{Keys: map[string]string{"caller": "-"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoder, err := newLogEncoder(LogEncodingLogfmt, encoderConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf strings.Builder
+	core := zapcore.NewCore(encoder, zapcore.AddSync(&buf), zapcore.DebugLevel)
+	logger := zap.New(core).With(zap.String("service", "api"), zap.Namespace("http"), zap.String("method", "GET"))
+	logger = logger.With(zap.Namespace("response"), zap.Int("status", 200))
+
+	logger.Info("first", zap.Int("bytes", 5))
+	logger.With(zap.String("cache", "hit")).Info("second")
+	logger.Info("third")
+
+	want := []string{
+		`msg=first http.method=GET http.response.bytes=5 http.response.status=200 service=api`,
+		`msg=second http.method=GET http.response.cache=hit http.response.status=200 service=api`,
+		`msg=third http.method=GET http.response.status=200 service=api`,
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d: %s", len(lines), len(want), buf.String())
+	}
+	for i, line := range lines {
+		if !strings.HasSuffix(line, want[i]) {
+			t.Errorf("got  %s\nwant ...%s", line, want[i])
+		}
+	}
+}
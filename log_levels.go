@@ -0,0 +1,257 @@
+package october
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Log levels adjustable at runtime, for the root logger and individual named loggers.
+// A named level applies to the logger and its children, e.g. a level for "OCTOBER" also applies to "OCTOBER.grpc".
+type LogLevels struct {
+	lock    sync.RWMutex
+	root    zap.AtomicLevel
+	named   map[string]zapcore.Level
+	reverts map[string]*logLevelRevert // By logger name, "" for the root level
+
+	minLevel int32 // Lowest enabled level across root and named levels, checked before every entry, accessed atomically
+}
+
+type logLevelRevert struct {
+	timer       *time.Timer
+	at          time.Time
+	previous    zapcore.Level // Level restored by the revert
+	hadPrevious bool          // False if the named logger had no level of its own, which is removed instead
+}
+
+func NewLogLevels(root zapcore.Level) *LogLevels {
+	l := &LogLevels{
+		root:     zap.NewAtomicLevelAt(root),
+		named:    make(map[string]zapcore.Level),
+		reverts:  make(map[string]*logLevelRevert),
+		minLevel: int32(root),
+	}
+
+	return l
+}
+
+// Returns the root level, used by loggers without a named level
+func (l *LogLevels) Level() zapcore.Level {
+	return l.root.Level()
+}
+
+// Returns the level applied to the named logger
+func (l *LogLevels) LevelFor(name string) zapcore.Level {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	return l.levelFor(name)
+}
+
+// Returns every named level
+func (l *LogLevels) NamedLevels() map[string]zapcore.Level {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	named := make(map[string]zapcore.Level, len(l.named))
+	for name, level := range l.named {
+		named[name] = level
+	}
+
+	return named
+}
+
+// Set the level of the named logger, or the root level if name is empty.
+// If revertAfter is positive the previous level is restored after that long. If a revert is already pending,
+// the level it restores is kept and only its time is reset, so repeated temporary changes still end at the original level.
+func (l *LogLevels) SetLevel(name string, level zapcore.Level, revertAfter time.Duration) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	previous, hadPrevious := l.named[name]
+	if name == "" {
+		previous, hadPrevious = l.root.Level(), true
+	}
+
+	if pending, ok := l.reverts[name]; ok {
+		previous, hadPrevious = pending.previous, pending.hadPrevious
+	}
+
+	l.setLevel(name, level)
+
+	l.cancelRevert(name)
+	if revertAfter > 0 {
+		revert := &logLevelRevert{
+			at:          time.Now().Add(revertAfter),
+			previous:    previous,
+			hadPrevious: hadPrevious,
+		}
+		revert.timer = time.AfterFunc(revertAfter, func() {
+			l.lock.Lock()
+			defer l.lock.Unlock()
+
+			// Replaced or cancelled after the timer fired, while waiting for the lock
+			if l.reverts[name] != revert {
+				return
+			}
+
+			delete(l.reverts, name)
+			if revert.hadPrevious {
+				l.setLevel(name, revert.previous)
+			} else {
+				delete(l.named, name)
+				l.updateMinLevel()
+			}
+		})
+		l.reverts[name] = revert
+	}
+}
+
+// Remove the level of the named logger, so it follows its parent or the root level
+func (l *LogLevels) ResetLevel(name string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.cancelRevert(name)
+	delete(l.named, name)
+	l.updateMinLevel()
+}
+
+// Returns when the level of the named logger, or root if empty, reverts. Returns false if it isn't scheduled to revert.
+func (l *LogLevels) RevertsAt(name string) (time.Time, bool) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	revert, ok := l.reverts[name]
+	if !ok {
+		return time.Time{}, false
+	}
+
+	return revert.at, true
+}
+
+// Returns true if an entry at level from the named logger should be logged
+func (l *LogLevels) Enabled(name string, level zapcore.Level) bool {
+	if level < zapcore.Level(atomic.LoadInt32(&l.minLevel)) {
+		return false
+	}
+
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	return level >= l.levelFor(name)
+}
+
+// Wraps core so entries are filtered by these levels. core itself must enable every level these levels may enable.
+func (l *LogLevels) WrapCore(core zapcore.Core) zapcore.Core {
+	return &levelFilterCore{Core: core, levels: l}
+}
+
+func (l *LogLevels) setLevel(name string, level zapcore.Level) {
+	if name == "" {
+		l.root.SetLevel(level)
+	} else {
+		l.named[name] = level
+	}
+
+	l.updateMinLevel()
+}
+
+func (l *LogLevels) cancelRevert(name string) {
+	if revert, ok := l.reverts[name]; ok {
+		revert.timer.Stop()
+		delete(l.reverts, name)
+	}
+}
+
+// Uses the level of the longest name matching name or one of its parents, or the root level
+func (l *LogLevels) levelFor(name string) zapcore.Level {
+	for name != "" {
+		if level, ok := l.named[name]; ok {
+			return level
+		}
+
+		dot := strings.LastIndex(name, ".")
+		if dot < 0 {
+			break
+		}
+		name = name[:dot]
+	}
+
+	return l.root.Level()
+}
+
+func (l *LogLevels) updateMinLevel() {
+	min := l.root.Level()
+	for _, level := range l.named {
+		if level < min {
+			min = level
+		}
+	}
+
+	atomic.StoreInt32(&l.minLevel, int32(min))
+}
+
+func (l *LogLevels) String() string {
+	named := l.NamedLevels()
+
+	var names []string
+	for name := range named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := []string{l.Level().String()}
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s=%s", name, named[name]))
+	}
+
+	return strings.Join(parts, ",")
+}
+
+type levelFilterCore struct {
+	zapcore.Core
+	levels *LogLevels
+}
+
+func (c *levelFilterCore) Enabled(level zapcore.Level) bool {
+	return level >= zapcore.Level(atomic.LoadInt32(&c.levels.minLevel))
+}
+
+func (c *levelFilterCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelFilterCore{Core: c.Core.With(fields), levels: c.levels}
+}
+
+func (c *levelFilterCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.levels.Enabled(entry.LoggerName, entry.Level) {
+		return checked
+	}
+
+	return c.Core.Check(entry, checked)
+}
+
+var (
+	globalLogLevelsLock sync.RWMutex
+	globalLogLevels     *LogLevels
+)
+
+// Returns the levels of the logger installed by ConfigureZap, nil if it hasn't been called
+func GlobalLogLevels() *LogLevels {
+	globalLogLevelsLock.RLock()
+	defer globalLogLevelsLock.RUnlock()
+
+	return globalLogLevels
+}
+
+func setGlobalLogLevels(levels *LogLevels) {
+	globalLogLevelsLock.Lock()
+	defer globalLogLevelsLock.Unlock()
+
+	globalLogLevels = levels
+}
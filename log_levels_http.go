@@ -0,0 +1,154 @@
+package october
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Body of GET /debug/loglevel, and of PUT and DELETE responses
+type logLevelsResponse struct {
+	Level    string                         `json:"level"`
+	RevertAt *time.Time                     `json:"revert_at,omitempty"`
+	Loggers  map[string]loggerLevelResponse `json:"loggers"`
+}
+
+type loggerLevelResponse struct {
+	Level    string     `json:"level"`
+	RevertAt *time.Time `json:"revert_at,omitempty"`
+}
+
+// Body of PUT /debug/loglevel, each may also be given as a query parameter
+type logLevelRequest struct {
+	Logger      string `json:"logger"`
+	Level       string `json:"level"`
+	RevertAfter string `json:"revert_after"`
+}
+
+// Serves the levels of the global logger.
+// GET returns the root and named levels.
+// PUT sets a level, e.g. {"logger": "grpc", "level": "debug", "revert_after": "10m"}, an empty logger sets the root level.
+// DELETE removes the level of ?logger=name, so it follows its parent again.
+func logLevelHTTPHandler() func(http.ResponseWriter, *http.Request) {
+
+	return func(write http.ResponseWriter, req *http.Request) {
+		levels := GlobalLogLevels()
+		if levels == nil {
+			writeLogLevelError(write, http.StatusServiceUnavailable, errors.New("zap has not been configured by October"))
+			return
+		}
+
+		switch req.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			err := setLogLevelFromRequest(levels, req)
+			if err != nil {
+				writeLogLevelError(write, http.StatusBadRequest, err)
+				return
+			}
+		case http.MethodDelete:
+			name := req.URL.Query().Get("logger")
+			if name == "" {
+				writeLogLevelError(write, http.StatusBadRequest, errors.New("logger is required, the root level can't be removed"))
+				return
+			}
+
+			levels.ResetLevel(name)
+			zap.L().Named("OCTOBER").Info("Removed log level", zap.String("target_logger", name))
+		default:
+			write.Header().Set("Allow", "GET, PUT, DELETE")
+			writeLogLevelError(write, http.StatusMethodNotAllowed, errors.Errorf("method %s not allowed", req.Method))
+			return
+		}
+
+		writeLogLevelJSON(write, http.StatusOK, newLogLevelsResponse(levels))
+	}
+}
+
+func setLogLevelFromRequest(levels *LogLevels, req *http.Request) error {
+	var body logLevelRequest
+
+	if req.ContentLength != 0 {
+		err := json.NewDecoder(req.Body).Decode(&body)
+		if err != nil {
+			return errors.Wrap(err, "invalid request body")
+		}
+	}
+
+	query := req.URL.Query()
+	if query.Get("logger") != "" {
+		body.Logger = query.Get("logger")
+	}
+	if query.Get("level") != "" {
+		body.Level = query.Get("level")
+	}
+	if query.Get("revert_after") != "" {
+		body.RevertAfter = query.Get("revert_after")
+	}
+
+	if body.Level == "" {
+		return errors.New("level is required")
+	}
+
+	var level zapcore.Level
+	err := level.UnmarshalText([]byte(body.Level))
+	if err != nil {
+		return err
+	}
+
+	var revertAfter time.Duration
+	if body.RevertAfter != "" {
+		revertAfter, err = time.ParseDuration(body.RevertAfter)
+		if err != nil {
+			return errors.Wrap(err, "invalid revert_after")
+		}
+		if revertAfter < 0 {
+			return errors.New("revert_after must not be negative")
+		}
+	}
+
+	levels.SetLevel(body.Logger, level, revertAfter)
+
+	zap.L().Named("OCTOBER").Info("Set log level",
+		zap.String("target_logger", body.Logger),
+		zap.Stringer("target_level", level),
+		zap.Duration("revert_after", revertAfter),
+	)
+
+	return nil
+}
+
+func newLogLevelsResponse(levels *LogLevels) logLevelsResponse {
+	response := logLevelsResponse{
+		Level:   levels.Level().String(),
+		Loggers: make(map[string]loggerLevelResponse),
+	}
+
+	if at, ok := levels.RevertsAt(""); ok {
+		response.RevertAt = &at
+	}
+
+	for name, level := range levels.NamedLevels() {
+		logger := loggerLevelResponse{Level: level.String()}
+		if at, ok := levels.RevertsAt(name); ok {
+			logger.RevertAt = &at
+		}
+		response.Loggers[name] = logger
+	}
+
+	return response
+}
+
+func writeLogLevelError(write http.ResponseWriter, status int, err error) {
+	writeLogLevelJSON(write, status, map[string]string{"error": err.Error()})
+}
+
+func writeLogLevelJSON(write http.ResponseWriter, status int, body interface{}) {
+	write.Header().Set("Content-Type", "application/json")
+	write.WriteHeader(status)
+	json.NewEncoder(write).Encode(body)
+}
@@ -0,0 +1,192 @@
+package october
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Short enough to keep tests fast, long enough for every step of a test to run before it elapses
+const testRevertAfter = 50 * time.Millisecond
+
+func TestLogLevelsSetLevel(t *testing.T) {
+	type step struct {
+		name        string
+		level       zapcore.Level
+		revertAfter time.Duration
+	}
+
+	tests := []struct {
+		name   string
+		steps  []step
+		before map[string]zapcore.Level // Levels after the steps, by logger name, "" for root
+		after  map[string]zapcore.Level // Levels once every revert has run
+	}{
+		{
+			name:   "permanent",
+			steps:  []step{{"", zapcore.DebugLevel, 0}, {"grpc", zapcore.WarnLevel, 0}},
+			before: map[string]zapcore.Level{"": zapcore.DebugLevel, "grpc": zapcore.WarnLevel, "grpc.client": zapcore.WarnLevel},
+			after:  map[string]zapcore.Level{"": zapcore.DebugLevel, "grpc": zapcore.WarnLevel},
+		},
+		{
+			name:   "root revert",
+			steps:  []step{{"", zapcore.DebugLevel, testRevertAfter}},
+			before: map[string]zapcore.Level{"": zapcore.DebugLevel},
+			after:  map[string]zapcore.Level{"": zapcore.InfoLevel},
+		},
+		{
+			name:   "named revert removes the level",
+			steps:  []step{{"grpc", zapcore.DebugLevel, testRevertAfter}},
+			before: map[string]zapcore.Level{"grpc": zapcore.DebugLevel},
+			after:  map[string]zapcore.Level{"grpc": zapcore.InfoLevel},
+		},
+		{
+			name:   "named revert restores the level",
+			steps:  []step{{"grpc", zapcore.ErrorLevel, 0}, {"grpc", zapcore.DebugLevel, testRevertAfter}},
+			before: map[string]zapcore.Level{"grpc": zapcore.DebugLevel},
+			after:  map[string]zapcore.Level{"grpc": zapcore.ErrorLevel},
+		},
+		{
+			name:   "repeated revert keeps the original level",
+			steps:  []step{{"", zapcore.DebugLevel, testRevertAfter}, {"", zapcore.DebugLevel, testRevertAfter}},
+			before: map[string]zapcore.Level{"": zapcore.DebugLevel},
+			after:  map[string]zapcore.Level{"": zapcore.InfoLevel},
+		},
+		{
+			name:   "repeated named revert keeps no level",
+			steps:  []step{{"grpc", zapcore.DebugLevel, testRevertAfter}, {"grpc", zapcore.WarnLevel, testRevertAfter}},
+			before: map[string]zapcore.Level{"grpc": zapcore.WarnLevel},
+			after:  map[string]zapcore.Level{"grpc": zapcore.InfoLevel},
+		},
+		{
+			name:   "permanent change cancels revert",
+			steps:  []step{{"", zapcore.DebugLevel, testRevertAfter}, {"", zapcore.WarnLevel, 0}},
+			before: map[string]zapcore.Level{"": zapcore.WarnLevel},
+			after:  map[string]zapcore.Level{"": zapcore.WarnLevel},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			levels := NewLogLevels(zapcore.InfoLevel)
+			for _, step := range test.steps {
+				levels.SetLevel(step.name, step.level, step.revertAfter)
+			}
+
+			check := func(want map[string]zapcore.Level) {
+				t.Helper()
+
+				for name, level := range want {
+					if got := levels.LevelFor(name); got != level {
+						t.Errorf("logger %q at %s, want %s", name, got, level)
+					}
+				}
+			}
+
+			check(test.before)
+			time.Sleep(3 * testRevertAfter)
+			check(test.after)
+
+			if _, ok := levels.RevertsAt(""); ok {
+				t.Error("root revert still pending")
+			}
+		})
+	}
+}
+
+func TestLogLevelsEnabled(t *testing.T) {
+	levels := NewLogLevels(zapcore.InfoLevel)
+	levels.SetLevel("OCTOBER", zapcore.WarnLevel, 0)
+	levels.SetLevel("OCTOBER.grpc", zapcore.DebugLevel, 0)
+
+	tests := []struct {
+		logger string
+		level  zapcore.Level
+		want   bool
+	}{
+		{"", zapcore.InfoLevel, true},
+		{"", zapcore.DebugLevel, false},
+		{"app", zapcore.InfoLevel, true},
+		{"OCTOBER", zapcore.InfoLevel, false},
+		{"OCTOBER.gin", zapcore.WarnLevel, true},
+		{"OCTOBER.gin", zapcore.InfoLevel, false},
+		{"OCTOBER.grpc", zapcore.DebugLevel, true},
+		{"OCTOBER.grpc.stream", zapcore.DebugLevel, true},
+		{"OCTOBERX", zapcore.InfoLevel, true},
+	}
+
+	for _, test := range tests {
+		if got := levels.Enabled(test.logger, test.level); got != test.want {
+			t.Errorf("%q at %s: got enabled %t, want %t", test.logger, test.level, got, test.want)
+		}
+	}
+
+	levels.ResetLevel("OCTOBER.grpc")
+	if levels.Enabled("OCTOBER.grpc", zapcore.InfoLevel) {
+		t.Error("reset logger doesn't follow its parent")
+	}
+}
+
+func TestLogLevelHTTPHandler(t *testing.T) {
+	previous := GlobalLogLevels()
+	defer setGlobalLogLevels(previous)
+
+	levels := NewLogLevels(zapcore.InfoLevel)
+	setGlobalLogLevels(levels)
+
+	handler := logLevelHTTPHandler()
+	serve := func(method, target, body string) (int, logLevelsResponse) {
+		t.Helper()
+
+		recorder := httptest.NewRecorder()
+		handler(recorder, httptest.NewRequest(method, target, strings.NewReader(body)))
+
+		var response logLevelsResponse
+		if recorder.Code == http.StatusOK {
+			err := json.NewDecoder(recorder.Body).Decode(&response)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		return recorder.Code, response
+	}
+
+	code, response := serve(http.MethodPut, "/debug/loglevel", `{"logger": "grpc", "level": "debug", "revert_after": "1h"}`)
+	if code != http.StatusOK || response.Loggers["grpc"].Level != "debug" || response.Loggers["grpc"].RevertAt == nil {
+		t.Errorf("PUT with body: got %d %+v", code, response)
+	}
+
+	code, response = serve(http.MethodPut, "/debug/loglevel?level=warn", "")
+	if code != http.StatusOK || response.Level != "warn" || response.RevertAt != nil {
+		t.Errorf("PUT with query: got %d %+v", code, response)
+	}
+
+	code, response = serve(http.MethodDelete, "/debug/loglevel?logger=grpc", "")
+	if _, ok := response.Loggers["grpc"]; code != http.StatusOK || ok {
+		t.Errorf("DELETE: got %d %+v", code, response)
+	}
+
+	for _, invalid := range []struct{ method, target, body string }{
+		{http.MethodPut, "/debug/loglevel", `{"level": "loud"}`},
+		{http.MethodPut, "/debug/loglevel?level=debug&revert_after=-1m", ""},
+		{http.MethodPut, "/debug/loglevel", `{"logger": "grpc"}`},
+		{http.MethodDelete, "/debug/loglevel", ""},
+	} {
+		if code, _ := serve(invalid.method, invalid.target, invalid.body); code != http.StatusBadRequest {
+			t.Errorf("%s %s %s: got %d, want %d", invalid.method, invalid.target, invalid.body, code, http.StatusBadRequest)
+		}
+	}
+
+	if code, _ := serve(http.MethodPost, "/debug/loglevel", ""); code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: got %d, want %d", code, http.StatusMethodNotAllowed)
+	}
+}
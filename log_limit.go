@@ -0,0 +1,299 @@
+package october
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Reasons entries are dropped, the reason label of logDroppedEntries
+const (
+	logDropDuplicate = "duplicate"
+	logDropRateLimit = "rate_limit"
+)
+
+var logDroppedEntries = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "october",
+		Subsystem: "log",
+		Name:      "dropped_entries_total",
+		Help:      "Log entries dropped by duplicate suppression or rate limiting, by logger, level and reason",
+	},
+	[]string{"logger", "level", "reason"},
+)
+
+// Limits of the entries a logger writes, zero values disable each limit
+type LogLimits struct {
+	DedupeWindow time.Duration // Identical entries within this window of the first are dropped, then summarized
+	RateLimit    int           // Entries per second per logger name and level
+	RateBurst    int           // Entries allowed at once above RateLimit, defaults to RateLimit
+}
+
+// Most distinct messages tracked for duplicates, expired messages are removed beyond this
+const maxDedupeMessages = 10000
+
+// Wraps core, dropping duplicate entries and entries over the rate limit. DPanic and above are never dropped.
+// Duplicates are summarized with a single entry once the window ends, e.g. "connection refused (repeated 120 times)".
+func (l LogLimits) WrapCore(core zapcore.Core) zapcore.Core {
+	if l.DedupeWindow <= 0 && l.RateLimit <= 0 {
+		return core
+	}
+
+	registerLogMetrics()
+
+	burst := l.RateBurst
+	if burst <= 0 {
+		burst = l.RateLimit
+	}
+
+	return &limitCore{
+		Core: core,
+		limiter: &logLimiter{
+			limits:     l,
+			burst:      float64(burst),
+			duplicates: make(map[logDedupeKey]*logDuplicates),
+			buckets:    make(map[logRateKey]*logTokenBucket),
+		},
+	}
+}
+
+// Entries are duplicates if their logger, level, message and fields are the same
+type logDedupeKey struct {
+	logger  string
+	level   zapcore.Level
+	message string
+	fields  uint64 // Hash of the encoded fields, see hashLogFields
+}
+
+type logDuplicates struct {
+	start      time.Time
+	dropped    int
+	timer      *time.Timer  // Summarizes the duplicates once the window ends, nil until the first duplicate
+	summarized bool         // Set once by whichever of the timer or a flush summarizes first
+	core       zapcore.Core // Writes the summary, with the fields of the first entry's logger
+}
+
+type logRateKey struct {
+	logger string
+	level  zapcore.Level
+}
+
+type logTokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// State shared by a limitCore and the cores derived from it with With
+type logLimiter struct {
+	lock       sync.Mutex
+	limits     LogLimits
+	burst      float64
+	duplicates map[logDedupeKey]*logDuplicates
+	buckets    map[logRateKey]*logTokenBucket
+}
+
+// Returns the reason entry should be dropped, empty if it should be written. fields is the hash of the entry's fields.
+func (l *logLimiter) check(entry zapcore.Entry, fields uint64, core zapcore.Core) string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := entry.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	if l.limits.DedupeWindow > 0 {
+		key := logDedupeKey{logger: entry.LoggerName, level: entry.Level, message: entry.Message, fields: fields}
+
+		if duplicates, ok := l.duplicates[key]; ok && now.Sub(duplicates.start) < l.limits.DedupeWindow {
+			if duplicates.timer == nil {
+				// The summary is scheduled on the first duplicate, so distinct messages don't each start a timer
+				duplicates.timer = time.AfterFunc(l.limits.DedupeWindow-now.Sub(duplicates.start), func() {
+					l.summarize(key, duplicates)
+				})
+			}
+			duplicates.dropped++
+
+			return logDropDuplicate
+		}
+
+		if len(l.duplicates) >= maxDedupeMessages {
+			l.removeExpired(now)
+		}
+		l.duplicates[key] = &logDuplicates{start: now, core: core}
+	}
+
+	if !l.allow(logRateKey{logger: entry.LoggerName, level: entry.Level}, now) {
+		return logDropRateLimit
+	}
+
+	return ""
+}
+
+// Takes a token from the rate limit bucket of key, returns false if it's empty. Must hold lock.
+func (l *logLimiter) allow(key logRateKey, now time.Time) bool {
+	if l.limits.RateLimit <= 0 {
+		return true
+	}
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &logTokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = bucket
+	}
+
+	bucket.tokens += now.Sub(bucket.last).Seconds() * float64(l.limits.RateLimit)
+	if bucket.tokens > l.burst {
+		bucket.tokens = l.burst
+	}
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+
+	return true
+}
+
+// Writes a summary of the duplicates dropped in the window, once it has ended or the logger is synced.
+// The summary counts against the rate limit like any other entry.
+func (l *logLimiter) summarize(key logDedupeKey, duplicates *logDuplicates) {
+	now := time.Now()
+
+	l.lock.Lock()
+	if duplicates.summarized {
+		l.lock.Unlock()
+		return
+	}
+	duplicates.summarized = true
+	duplicates.timer.Stop()
+	if l.duplicates[key] == duplicates {
+		delete(l.duplicates, key)
+	}
+
+	dropped := duplicates.dropped
+	allowed := l.allow(logRateKey{logger: key.logger, level: key.level}, now)
+	l.lock.Unlock()
+
+	if !allowed {
+		logDroppedEntries.WithLabelValues(key.logger, key.level.String(), logDropRateLimit).Inc()
+		return
+	}
+
+	summary := zapcore.Entry{
+		Level:      key.level,
+		Time:       now,
+		LoggerName: key.logger,
+		Message:    fmt.Sprintf("%s (repeated %d times)", key.message, dropped),
+	}
+
+	if checked := duplicates.core.Check(summary, nil); checked != nil {
+		checked.Write(
+			zap.Int("repeated", dropped),
+			zap.Duration("repeated_window", l.limits.DedupeWindow),
+		)
+	}
+}
+
+// Summarizes every pending window of duplicates now
+func (l *logLimiter) flush() {
+	pending := make(map[logDedupeKey]*logDuplicates)
+
+	l.lock.Lock()
+	for key, duplicates := range l.duplicates {
+		if duplicates.dropped > 0 {
+			pending[key] = duplicates
+		}
+	}
+	l.lock.Unlock()
+
+	for key, duplicates := range pending {
+		l.summarize(key, duplicates)
+	}
+}
+
+// Removes messages whose window has ended without duplicates. Must hold lock.
+func (l *logLimiter) removeExpired(now time.Time) {
+	for key, duplicates := range l.duplicates {
+		if duplicates.dropped == 0 && now.Sub(duplicates.start) >= l.limits.DedupeWindow {
+			delete(l.duplicates, key)
+		}
+	}
+}
+
+// Encoder for the fields of entries compared for duplicates, writing only the fields
+var logDedupeFieldEncoder = zapcore.NewJSONEncoder(zapcore.EncoderConfig{})
+
+// Returns the hash of fields added to the hash of the fields before them, seed.
+// Only computed when duplicates are dropped, encoding fields isn't free.
+func (l *logLimiter) hashLogFields(seed uint64, fields []zapcore.Field) uint64 {
+	if l.limits.DedupeWindow <= 0 || len(fields) == 0 {
+		return seed
+	}
+
+	buf, err := logDedupeFieldEncoder.EncodeEntry(zapcore.Entry{}, fields)
+	if err != nil {
+		return seed
+	}
+	defer buf.Free()
+
+	hash := fnv.New64a()
+	binary.Write(hash, binary.LittleEndian, seed)
+	hash.Write(buf.Bytes())
+
+	return hash.Sum64()
+}
+
+type limitCore struct {
+	zapcore.Core
+	limiter *logLimiter
+	fields  uint64 // Hash of the fields added with With
+}
+
+func (c *limitCore) With(fields []zapcore.Field) zapcore.Core {
+	return &limitCore{
+		Core:    c.Core.With(fields),
+		limiter: c.limiter,
+		fields:  c.limiter.hashLogFields(c.fields, fields),
+	}
+}
+
+func (c *limitCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(entry.Level) {
+		return checked
+	}
+
+	if entry.Level >= zapcore.DPanicLevel {
+		return c.Core.Check(entry, checked)
+	}
+
+	// Duplicates are told apart by their fields, which are only known once the entry is written
+	return checked.AddCore(entry, c)
+}
+
+func (c *limitCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if reason := c.limiter.check(entry, c.limiter.hashLogFields(c.fields, fields), c.Core); reason != "" {
+		logDroppedEntries.WithLabelValues(entry.LoggerName, entry.Level.String(), reason).Inc()
+		return nil
+	}
+
+	// Checked again so the wrapped cores' own levels and sampling apply
+	if checked := c.Core.Check(entry, nil); checked != nil {
+		checked.Write(fields...)
+	}
+
+	return nil
+}
+
+// Writes the summaries of pending duplicates, then syncs the wrapped core
+func (c *limitCore) Sync() error {
+	c.limiter.flush()
+	return c.Core.Sync()
+}
@@ -0,0 +1,149 @@
+package october
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogLimiterCheck(t *testing.T) {
+	start := time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	type write struct {
+		after   time.Duration // Since start
+		logger  string
+		message string
+		fields  uint64
+		want    string // Drop reason, empty if written
+	}
+
+	tests := []struct {
+		name   string
+		limits LogLimits
+		writes []write
+	}{
+		{
+			name:   "duplicates in window",
+			limits: LogLimits{DedupeWindow: time.Minute},
+			writes: []write{
+				{0, "app", "refused", 0, ""},
+				{time.Second, "app", "refused", 0, logDropDuplicate},
+				{time.Second, "app", "accepted", 0, ""},
+				{time.Second, "grpc", "refused", 0, ""},
+				{time.Minute, "app", "refused", 0, ""},
+			},
+		},
+		{
+			name:   "different fields",
+			limits: LogLimits{DedupeWindow: time.Minute},
+			writes: []write{
+				{0, "gin", "GET /", 1, ""},
+				{time.Second, "gin", "GET /", 2, ""},
+				{time.Second, "gin", "GET /", 1, logDropDuplicate},
+			},
+		},
+		{
+			name:   "rate limit",
+			limits: LogLimits{RateLimit: 2},
+			writes: []write{
+				{0, "app", "a", 0, ""},
+				{0, "app", "b", 0, ""},
+				{0, "app", "c", 0, logDropRateLimit},
+				{0, "grpc", "c", 0, ""},
+				{500 * time.Millisecond, "app", "d", 0, ""},
+				{500 * time.Millisecond, "app", "e", 0, logDropRateLimit},
+			},
+		},
+		{
+			name:   "burst",
+			limits: LogLimits{RateLimit: 1, RateBurst: 3},
+			writes: []write{
+				{0, "app", "a", 0, ""},
+				{0, "app", "b", 0, ""},
+				{0, "app", "c", 0, ""},
+				{0, "app", "d", 0, logDropRateLimit},
+			},
+		},
+		{
+			name:   "duplicates take no tokens",
+			limits: LogLimits{DedupeWindow: time.Minute, RateLimit: 1, RateBurst: 2},
+			writes: []write{
+				{0, "app", "a", 0, ""},
+				{0, "app", "a", 0, logDropDuplicate},
+				{0, "app", "b", 0, ""},
+				{0, "app", "c", 0, logDropRateLimit},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			limiter := test.limits.WrapCore(zapcore.NewNopCore()).(*limitCore).limiter
+
+			for i, write := range test.writes {
+				entry := zapcore.Entry{Time: start.Add(write.after), LoggerName: write.logger, Level: zapcore.InfoLevel, Message: write.message}
+				if got := limiter.check(entry, write.fields, zapcore.NewNopCore()); got != write.want {
+					t.Errorf("write %d %s %q: got %q, want %q", i, write.logger, write.message, got, write.want)
+				}
+			}
+
+			// Stop pending summaries, the nop core discards them
+			limiter.flush()
+		})
+	}
+}
+
+func TestLimitCoreFields(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(LogLimits{DedupeWindow: time.Minute}.WrapCore(core))
+
+	for _, status := range []int{200, 404, 200} {
+		logger.Info("GET /users", zap.Int("status", status))
+	}
+	logger.With(zap.String("request_id", "a")).Info("GET /users", zap.Int("status", 200))
+
+	if n := logs.Len(); n != 3 {
+		t.Errorf("got %d entries, want 3, entries with different fields aren't duplicates", n)
+	}
+}
+
+func TestLimitCoreSyncSummarizes(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(LogLimits{DedupeWindow: time.Hour}.WrapCore(core))
+
+	for i := 0; i < 4; i++ {
+		logger.Warn("connection refused")
+	}
+	logger.Sync()
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want the first and a summary", len(entries))
+	}
+	summary := entries[1]
+	if summary.Message != "connection refused (repeated 3 times)" || summary.ContextMap()["repeated"] != int64(3) {
+		t.Errorf("got summary %q %v", summary.Message, summary.ContextMap())
+	}
+
+	// The window's timer doesn't summarize again
+	logger.Sync()
+	if logs.Len() != 2 {
+		t.Errorf("got %d entries after a second sync, want 2", logs.Len())
+	}
+}
+
+func TestLimitCoreSummaryRateLimited(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(LogLimits{DedupeWindow: time.Hour, RateLimit: 1}.WrapCore(core))
+
+	logger.Info("refused")
+	logger.Info("refused")
+	logger.Sync()
+
+	if n := logs.FilterMessageSnippet("repeated").Len(); n != 0 {
+		t.Errorf("got %d summaries past the rate limit, want 0", n)
+	}
+}
@@ -0,0 +1,252 @@
+package october
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+var logfmtBufferPool = buffer.NewPool()
+
+// Encodes entries as logfmt, e.g. ts=2020-01-02T15:04:05.000Z level=INFO msg="Starting server" port=8080.
+// Entry keys come first, followed by fields in key order. Nested objects are flattened into dotted keys,
+// arrays and other values that can't be written as a single token are written as quoted JSON.
+type logfmtEncoder struct {
+	*zapcore.MapObjectEncoder
+	config     zapcore.EncoderConfig
+	namespaces []string // Open namespaces, outermost first, which the MapObjectEncoder doesn't expose
+}
+
+func newLogfmtEncoder(config zapcore.EncoderConfig) zapcore.Encoder {
+	return &logfmtEncoder{
+		MapObjectEncoder: zapcore.NewMapObjectEncoder(),
+		config:           config,
+	}
+}
+
+func (e *logfmtEncoder) OpenNamespace(key string) {
+	e.MapObjectEncoder.OpenNamespace(key)
+	e.namespaces = append(e.namespaces, key)
+}
+
+// Copies the fields and reopens every open namespace, so fields added to the clone land in the same namespace
+func (e *logfmtEncoder) Clone() zapcore.Encoder {
+	clone := &logfmtEncoder{
+		MapObjectEncoder: zapcore.NewMapObjectEncoder(),
+		config:           e.config,
+	}
+
+	src, dst := e.Fields, clone.Fields
+	for _, namespace := range e.namespaces {
+		for key, value := range src {
+			if key != namespace {
+				dst[key] = copyLogfmtValue(value)
+			}
+		}
+
+		// Opening the namespace on the clone moves its cursor, the namespace's map is then filled in directly
+		clone.OpenNamespace(namespace)
+		src, _ = src[namespace].(map[string]interface{})
+		dst = dst[namespace].(map[string]interface{})
+	}
+
+	for key, value := range src {
+		dst[key] = copyLogfmtValue(value)
+	}
+
+	return clone
+}
+
+func (e *logfmtEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	final := e.Clone().(*logfmtEncoder)
+	for _, field := range fields {
+		field.AddTo(final)
+	}
+
+	buf := logfmtBufferPool.Get()
+	cfg := e.config
+
+	if cfg.TimeKey != "" && cfg.EncodeTime != nil {
+		e.writePair(buf, cfg.TimeKey, encodeLogPrimitive(func(enc zapcore.PrimitiveArrayEncoder) {
+			cfg.EncodeTime(entry.Time, enc)
+		}))
+	}
+
+	if cfg.LevelKey != "" && cfg.EncodeLevel != nil {
+		e.writePair(buf, cfg.LevelKey, encodeLogPrimitive(func(enc zapcore.PrimitiveArrayEncoder) {
+			cfg.EncodeLevel(entry.Level, enc)
+		}))
+	}
+
+	if cfg.NameKey != "" && entry.LoggerName != "" {
+		e.writePair(buf, cfg.NameKey, entry.LoggerName)
+	}
+
+	if cfg.CallerKey != "" && entry.Caller.Defined && cfg.EncodeCaller != nil {
+		e.writePair(buf, cfg.CallerKey, encodeLogPrimitive(func(enc zapcore.PrimitiveArrayEncoder) {
+			cfg.EncodeCaller(entry.Caller, enc)
+		}))
+	}
+
+	if cfg.FunctionKey != "" && entry.Caller.Defined && entry.Caller.Function != "" {
+		e.writePair(buf, cfg.FunctionKey, entry.Caller.Function)
+	}
+
+	if cfg.MessageKey != "" {
+		e.writePair(buf, cfg.MessageKey, entry.Message)
+	}
+
+	flat := make(map[string]interface{})
+	flattenLogfmtFields("", final.Fields, flat)
+
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		e.writePair(buf, key, flat[key])
+	}
+
+	if cfg.StacktraceKey != "" && entry.Stack != "" {
+		e.writePair(buf, cfg.StacktraceKey, entry.Stack)
+	}
+
+	if cfg.LineEnding != "" {
+		buf.AppendString(cfg.LineEnding)
+	} else {
+		buf.AppendString(zapcore.DefaultLineEnding)
+	}
+
+	return buf, nil
+}
+
+func (e *logfmtEncoder) writePair(buf *buffer.Buffer, key string, value interface{}) {
+	if buf.Len() > 0 {
+		buf.AppendByte(' ')
+	}
+
+	buf.AppendString(logfmtKey(key))
+	buf.AppendByte('=')
+	buf.AppendString(e.formatValue(value))
+}
+
+func (e *logfmtEncoder) formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return logfmtString(v)
+	case []byte:
+		return logfmtString(string(v))
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		if e.config.EncodeTime != nil {
+			return e.formatValue(encodeLogPrimitive(func(enc zapcore.PrimitiveArrayEncoder) {
+				e.config.EncodeTime(v, enc)
+			}))
+		}
+		return logfmtString(v.Format(time.RFC3339Nano))
+	case time.Duration:
+		if e.config.EncodeDuration != nil {
+			return e.formatValue(encodeLogPrimitive(func(enc zapcore.PrimitiveArrayEncoder) {
+				e.config.EncodeDuration(v, enc)
+			}))
+		}
+		return v.String()
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, uintptr, float32, float64, complex64, complex128:
+		return fmt.Sprint(v)
+	case fmt.Stringer:
+		return logfmtString(v.String())
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return logfmtString(fmt.Sprint(value))
+	}
+
+	return logfmtString(string(encoded))
+}
+
+// Runs encode against a primitive array encoder, returning the single value it appends
+func encodeLogPrimitive(encode func(zapcore.PrimitiveArrayEncoder)) interface{} {
+	enc := zapcore.NewMapObjectEncoder()
+	enc.AddArray("v", zapcore.ArrayMarshalerFunc(func(arr zapcore.ArrayEncoder) error {
+		encode(arr)
+		return nil
+	}))
+
+	values, _ := enc.Fields["v"].([]interface{})
+	if len(values) == 0 {
+		return nil
+	}
+
+	return values[0]
+}
+
+// Nested objects, including namespaces, become dotted keys
+func flattenLogfmtFields(prefix string, fields map[string]interface{}, flat map[string]interface{}) {
+	for key, value := range fields {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		if nested, ok := value.(map[string]interface{}); ok {
+			flattenLogfmtFields(key, nested, flat)
+			continue
+		}
+
+		flat[key] = value
+	}
+}
+
+func copyLogfmtValue(value interface{}) interface{} {
+	nested, ok := value.(map[string]interface{})
+	if !ok {
+		return value
+	}
+
+	clone := make(map[string]interface{}, len(nested))
+	for key, nestedValue := range nested {
+		clone[key] = copyLogfmtValue(nestedValue)
+	}
+
+	return clone
+}
+
+// Keys can't be quoted, so characters that would end a key are replaced
+func logfmtKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+
+	return strings.Map(func(r rune) rune {
+		if r == '=' || r == '"' || unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+func logfmtString(s string) string {
+	if s == "" {
+		return `""`
+	}
+
+	for _, r := range s {
+		if r == '=' || r == '"' || r == '\\' || unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			return strconv.Quote(s)
+		}
+	}
+
+	return s
+}
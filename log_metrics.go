@@ -0,0 +1,56 @@
+package october
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var logEntries = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "october",
+		Subsystem: "log",
+		Name:      "entries_total",
+		Help:      "Log entries written by loggers built by October, by logger and level",
+	},
+	[]string{"logger", "level"},
+)
+
+var registerLogMetricsOnce sync.Once
+
+// Registers the log metrics with the default registry the first time a logger needs them,
+// so importing October doesn't register them, or panic when they're already registered
+func registerLogMetrics() {
+	registerLogMetricsOnce.Do(func() {
+		logEntries = registerLogCounter(logEntries)
+		logDroppedEntries = registerLogCounter(logDroppedEntries)
+	})
+}
+
+// Registers counter, returning the counter registered before it if there is one
+func registerLogCounter(counter *prometheus.CounterVec) *prometheus.CounterVec {
+	err := prometheus.Register(counter)
+	if err == nil {
+		return counter
+	}
+
+	var already prometheus.AlreadyRegisteredError
+	if errors.As(err, &already) {
+		if existing, ok := already.ExistingCollector.(*prometheus.CounterVec); ok {
+			return existing
+		}
+	}
+
+	zap.L().Named("OCTOBER").Warn("Failed to register log metric", zap.Error(err))
+
+	return counter
+}
+
+// zap hook counting written entries, installed on every logger built by October
+func countLogEntry(entry zapcore.Entry) error {
+	logEntries.WithLabelValues(entry.LoggerName, entry.Level.String()).Inc()
+	return nil
+}
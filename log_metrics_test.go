@@ -0,0 +1,61 @@
+package october
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLogMetrics(t *testing.T) {
+	built, err := buildZapLogger(PROD, LogConfig{OutputPaths: []string{filepath.Join(t.TempDir(), "app.log")}, Level: "info", DedupeWindow: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := built.logger.Named("metrics_test")
+
+	written := func() float64 {
+		return testutil.ToFloat64(logEntries.WithLabelValues("metrics_test", "warn"))
+	}
+	dropped := func() float64 {
+		return testutil.ToFloat64(logDroppedEntries.WithLabelValues("metrics_test", "warn", logDropDuplicate))
+	}
+	writtenBefore, droppedBefore := written(), dropped()
+
+	for i := 0; i < 3; i++ {
+		logger.Warn("metrics test")
+	}
+	logger.Debug("below the level")
+	logger.Sync()
+
+	// The first entry and the summary of the duplicates
+	if n := written() - writtenBefore; n != 2 {
+		t.Errorf("counted %v written entries, want 2", n)
+	}
+	if n := dropped() - droppedBefore; n != 2 {
+		t.Errorf("counted %v dropped entries, want 2", n)
+	}
+	if n := testutil.ToFloat64(logEntries.WithLabelValues("metrics_test", "debug")); n != 0 {
+		t.Errorf("counted %v entries below the level", n)
+	}
+}
+
+func TestRegisterLogCounter(t *testing.T) {
+	opts := prometheus.CounterOpts{Name: "october_test_registered_total", Help: "Test counter"}
+
+	first := registerLogCounter(prometheus.NewCounterVec(opts, []string{"logger", "level"}))
+	defer prometheus.Unregister(first)
+
+	second := registerLogCounter(prometheus.NewCounterVec(opts, []string{"logger", "level"}))
+	if second != first {
+		t.Error("already registered counter not reused")
+	}
+
+	first.WithLabelValues("app", zapcore.InfoLevel.String()).Inc()
+	if n := testutil.ToFloat64(second.WithLabelValues("app", "info")); n != 1 {
+		t.Errorf("got %v, want 1", n)
+	}
+}
@@ -0,0 +1,356 @@
+package october
+
+import (
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// An additional log output, tee'd with the logger's main output.
+// Parsed from URLs in LogConfig.Sinks by ParseLogSink, e.g.
+//
+//	file:///var/log/app/errors.log?level=error&max_size=100MB&max_backups=5&max_age=168h
+//	file:///var/log/app/app.log?interval=24h&encoding=logfmt
+//	unixgram:///run/collector.sock?level=info
+//	syslog:///dev/log?tag=app&facility=local0&level=warn
+//	syslog://logs.internal:514?tag=app
+//	stderr?level=error
+type LogSink struct {
+	Scheme   string        // One of file, unixgram, syslog, stdout or stderr
+	Path     string        // File or socket path
+	Address  string        // host:port of a syslog server, sent over UDP
+	Level    zapcore.Level // Minimum level written to this sink, in addition to the logger's levels
+	Encoding string        // One of the LogEncoding* encodings, defaults to the main output's encoding
+
+	Rotate RotateOptions // Files only
+
+	Tag      string // Syslog only, defaults to the executable name
+	Facility int    // Syslog only, defaults to user
+}
+
+func ParseLogSink(raw string) (LogSink, error) {
+	sink := LogSink{
+		Level: zapcore.DebugLevel,
+	}
+
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return sink, errors.New("empty log sink")
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return sink, errors.Wrapf(err, "log sink %s", raw)
+	}
+
+	switch {
+	case u.Scheme == "" && (u.Path == "stdout" || u.Path == "stderr"):
+		sink.Scheme = u.Path
+	case u.Scheme == "":
+		sink.Scheme = "file"
+		sink.Path = u.Path
+	default:
+		sink.Scheme = strings.ToLower(u.Scheme)
+		sink.Path = u.Path
+	}
+
+	query := u.Query()
+
+	if level := query.Get("level"); level != "" {
+		err := sink.Level.UnmarshalText([]byte(level))
+		if err != nil {
+			return sink, errors.Wrapf(err, "log sink %s", raw)
+		}
+	}
+
+	if encoding := query.Get("encoding"); encoding != "" {
+		_, err := logEncoderConfig(encoding, LogConfig{})
+		if err != nil {
+			return sink, errors.Wrapf(err, "log sink %s", raw)
+		}
+		sink.Encoding = encoding
+	}
+
+	switch sink.Scheme {
+	case "stdout", "stderr":
+	case "file":
+		if sink.Path == "" {
+			return sink, errors.Errorf("log sink %s: missing file path", raw)
+		}
+
+		err = parseRotateOptions(query, &sink.Rotate)
+		if err != nil {
+			return sink, errors.Wrapf(err, "log sink %s", raw)
+		}
+	case "unixgram":
+		if sink.Path == "" {
+			return sink, errors.Errorf("log sink %s: missing socket path", raw)
+		}
+	case "syslog":
+		if u.Host != "" {
+			sink.Address = u.Host
+			if u.Port() == "" {
+				sink.Address = net.JoinHostPort(u.Host, "514")
+			}
+		} else if sink.Path == "" {
+			sink.Path = "/dev/log"
+		}
+
+		sink.Tag = query.Get("tag")
+		if sink.Tag == "" && len(os.Args) > 0 {
+			sink.Tag = filepath.Base(os.Args[0])
+		}
+
+		sink.Facility = syslogFacilities["user"]
+		if facility := query.Get("facility"); facility != "" {
+			code, ok := syslogFacilities[strings.ToLower(facility)]
+			if !ok {
+				return sink, errors.Errorf("log sink %s: unknown syslog facility %s", raw, facility)
+			}
+			sink.Facility = code
+		}
+	default:
+		return sink, errors.Errorf("log sink %s: unsupported scheme %s", raw, sink.Scheme)
+	}
+
+	return sink, nil
+}
+
+func parseRotateOptions(query url.Values, options *RotateOptions) error {
+	var err error
+
+	if maxSize := query.Get("max_size"); maxSize != "" {
+		options.MaxSize, err = ParseByteSize(maxSize)
+		if err != nil {
+			return errors.Wrap(err, "max_size")
+		}
+	}
+
+	if interval := query.Get("interval"); interval != "" {
+		options.Interval, err = time.ParseDuration(interval)
+		if err != nil {
+			return errors.Wrap(err, "interval")
+		}
+	}
+
+	if maxAge := query.Get("max_age"); maxAge != "" {
+		options.MaxAge, err = time.ParseDuration(maxAge)
+		if err != nil {
+			return errors.Wrap(err, "max_age")
+		}
+	}
+
+	if maxBackups := query.Get("max_backups"); maxBackups != "" {
+		options.MaxBackups, err = strconv.Atoi(maxBackups)
+		if err != nil {
+			return errors.Wrap(err, "max_backups")
+		}
+	}
+
+	return nil
+}
+
+// Build a core writing to sink, encoding entries with encoderConfig.
+// Files and sockets opened for the core stay open for the life of the process.
+func (s LogSink) Core(encoderConfig zapcore.EncoderConfig) (zapcore.Core, error) {
+	core, _, err := s.open(encoderConfig)
+	return core, err
+}
+
+// Same as Core, also returning the file or socket the core writes to, nil for stdout and stderr
+func (s LogSink) open(encoderConfig zapcore.EncoderConfig) (zapcore.Core, io.Closer, error) {
+	encoding := s.Encoding
+	if encoding == "" {
+		encoding = LogEncodingJSON
+	}
+
+	encoder, err := newLogEncoder(encoding, encoderConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	level := zap.NewAtomicLevelAt(s.Level)
+
+	switch s.Scheme {
+	case "stdout":
+		return zapcore.NewCore(encoder, zapcore.Lock(os.Stdout), level), nil, nil
+	case "stderr":
+		return zapcore.NewCore(encoder, zapcore.Lock(os.Stderr), level), nil, nil
+	case "file":
+		file, err := NewRotatingFile(s.Path, s.Rotate)
+		if err != nil {
+			return nil, nil, err
+		}
+		return zapcore.NewCore(encoder, file, level), file, nil
+	case "unixgram":
+		out := newDatagramWriter("unixgram", s.Path)
+		return zapcore.NewCore(encoder, out, level), out, nil
+	case "syslog":
+		core := newSyslogCore(s, encoder, level)
+		return core, core.out, nil
+	}
+
+	return nil, nil, errors.Errorf("unsupported log sink scheme %s", s.Scheme)
+}
+
+// Parse and build a core for each sink, sinks without an encoding use encoding.
+// Also returns the files and sockets the cores write to, to be closed once the cores are no longer used.
+func newLogSinkCores(sinks []string, encoding string, logConfig LogConfig) ([]zapcore.Core, []io.Closer, error) {
+	var cores []zapcore.Core
+	var closers []io.Closer
+
+	// Anything opened before a later sink fails is closed again
+	fail := func(err error) ([]zapcore.Core, []io.Closer, error) {
+		closeLogSinks(closers)
+		return nil, nil, err
+	}
+
+	for _, raw := range sinks {
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+
+		sink, err := ParseLogSink(raw)
+		if err != nil {
+			return fail(err)
+		}
+
+		if sink.Encoding == "" {
+			sink.Encoding = encoding
+		}
+
+		encoderConfig, err := logEncoderConfig(sink.Encoding, logConfig)
+		if err != nil {
+			return fail(err)
+		}
+
+		// Sinks aren't terminals, so levels are written without colors
+		if sink.Encoding == LogEncodingConsole {
+			encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+		}
+
+		core, closer, err := sink.open(encoderConfig)
+		if err != nil {
+			return fail(errors.Wrapf(err, "log sink %s", raw))
+		}
+
+		cores = append(cores, core)
+		if closer != nil {
+			closers = append(closers, closer)
+		}
+	}
+
+	return cores, closers, nil
+}
+
+// Closes the files and sockets of sinks, logging rather than returning errors as the logger writing to them is gone
+func closeLogSinks(closers []io.Closer) {
+	for _, closer := range closers {
+		err := closer.Close()
+		if err != nil {
+			zap.L().Named("OCTOBER").Warn("Failed to close log sink", zap.Error(err))
+		}
+	}
+}
+
+// Backoff between redials of a datagramWriter whose receiver is unreachable, doubling after every failure
+const (
+	datagramMinBackoff = 100 * time.Millisecond
+	datagramMaxBackoff = 30 * time.Second
+)
+
+// Writes each Write as a single datagram. After an error the connection is redialed once straight away,
+// as the receiver may have restarted, then with backoff. Writes fail without dialing while backing off.
+type datagramWriter struct {
+	lock    sync.Mutex
+	network string
+	address string
+	conn    net.Conn
+	closed  bool
+	backoff time.Duration // Zero while the receiver is reachable
+	retryAt time.Time     // No redial before this while backing off
+}
+
+func newDatagramWriter(network, address string) *datagramWriter {
+	return &datagramWriter{network: network, address: address}
+}
+
+func (w *datagramWriter) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.closed {
+		return 0, errors.Errorf("%s %s is closed", w.network, w.address)
+	}
+
+	for attempt := 0; ; attempt++ {
+		if w.conn == nil {
+			if w.backoff > 0 && time.Now().Before(w.retryAt) {
+				return 0, errors.Errorf("%s %s is unreachable, retrying in %s", w.network, w.address, time.Until(w.retryAt).Round(time.Millisecond))
+			}
+
+			conn, err := net.Dial(w.network, w.address)
+			if err != nil {
+				w.failed()
+				return 0, err
+			}
+			w.conn = conn
+		}
+
+		n, err := w.conn.Write(p)
+		if err == nil {
+			w.backoff = 0
+			return n, nil
+		}
+
+		w.conn.Close()
+		w.conn = nil
+
+		if attempt > 0 || w.backoff > 0 {
+			w.failed()
+			return n, err
+		}
+	}
+}
+
+// Backs off redialing, must hold lock
+func (w *datagramWriter) failed() {
+	w.backoff *= 2
+	if w.backoff < datagramMinBackoff {
+		w.backoff = datagramMinBackoff
+	}
+	if w.backoff > datagramMaxBackoff {
+		w.backoff = datagramMaxBackoff
+	}
+
+	w.retryAt = time.Now().Add(w.backoff)
+}
+
+func (w *datagramWriter) Sync() error {
+	return nil
+}
+
+func (w *datagramWriter) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.closed = true
+	if w.conn == nil {
+		return nil
+	}
+
+	err := w.conn.Close()
+	w.conn = nil
+
+	return err
+}
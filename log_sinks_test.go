@@ -0,0 +1,159 @@
+package october
+
+import (
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestParseLogSink(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want LogSink
+		err  bool
+	}{
+		{raw: "stderr?level=error", want: LogSink{Scheme: "stderr", Level: zapcore.ErrorLevel}},
+		{raw: "/var/log/app.log", want: LogSink{Scheme: "file", Path: "/var/log/app.log", Level: zapcore.DebugLevel}},
+		{
+			raw: "file:///var/log/app.log?max_size=10MiB&max_backups=3&max_age=24h&interval=1h&encoding=logfmt",
+			want: LogSink{Scheme: "file", Path: "/var/log/app.log", Level: zapcore.DebugLevel, Encoding: LogEncodingLogfmt,
+				Rotate: RotateOptions{MaxSize: 10 * MiB, MaxBackups: 3, MaxAge: 24 * time.Hour, Interval: time.Hour}},
+		},
+		{raw: "unixgram:///run/collector.sock", want: LogSink{Scheme: "unixgram", Path: "/run/collector.sock", Level: zapcore.DebugLevel}},
+		{raw: "syslog://logs.internal?tag=app&facility=local0", want: LogSink{Scheme: "syslog", Address: "logs.internal:514", Level: zapcore.DebugLevel, Tag: "app", Facility: 16}},
+		{raw: "syslog:///dev/log?tag=app", want: LogSink{Scheme: "syslog", Path: "/dev/log", Level: zapcore.DebugLevel, Tag: "app", Facility: 1}},
+		{raw: "", err: true},
+		{raw: "file://", err: true},
+		{raw: "stderr?level=loud", err: true},
+		{raw: "stderr?encoding=xml", err: true},
+		{raw: "file:///var/log/app.log?max_size=lots", err: true},
+		{raw: "syslog:///dev/log?facility=nope", err: true},
+		{raw: "kafka://broker:9092", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.raw, func(t *testing.T) {
+			sink, err := ParseLogSink(test.raw)
+			if (err != nil) != test.err {
+				t.Fatalf("got error %v, want error %t", err, test.err)
+			}
+			if err == nil && sink != test.want {
+				t.Errorf("got %+v, want %+v", sink, test.want)
+			}
+		})
+	}
+}
+
+func TestLogSinkRouting(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.log")
+	errorsPath := filepath.Join(dir, "errors.log")
+
+	sampling := true
+	built, err := buildZapLogger(PROD, LogConfig{
+		OutputPaths:        []string{main},
+		Sinks:              []string{"file://" + errorsPath + "?level=error&encoding=logfmt"},
+		Sampling:           &sampling,
+		SamplingInitial:    1,
+		SamplingThereafter: 1000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10; i++ {
+		built.logger.Error("repeated")
+	}
+	built.logger.Info("info only")
+	built.logger.Sync()
+	closeLogSinks(built.sinks)
+
+	mainLines := strings.Count(readTestFile(t, main), "repeated")
+	if mainLines != 1 {
+		t.Errorf("main output has %d sampled entries, want 1", mainLines)
+	}
+
+	sinkOutput := readTestFile(t, errorsPath)
+	if n := strings.Count(sinkOutput, "msg=repeated"); n != 10 {
+		t.Errorf("sink has %d entries, want all 10 unsampled", n)
+	}
+	if strings.Contains(sinkOutput, "info only") {
+		t.Error("sink has an entry below its level")
+	}
+}
+
+func TestConfigureZapClosesSinks(t *testing.T) {
+	previous := zap.L()
+	defer func() {
+		zap.ReplaceGlobals(previous)
+		setGlobalLogLevels(nil)
+		setGlobalLogBuffer(nil)
+	}()
+
+	dir := t.TempDir()
+	logConfig := LogConfig{
+		OutputPaths: []string{filepath.Join(dir, "main.log")},
+		Sinks:       []string{"file://" + filepath.Join(dir, "sink.log")},
+	}
+
+	err := ConfigureZapWithConfig(PROD, logConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	globalLogSinksLock.Lock()
+	first := globalLogSinks[0].(*RotatingFile)
+	globalLogSinksLock.Unlock()
+
+	err = ConfigureZapWithConfig(PROD, logConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeLogSinks(globalLogSinks)
+
+	if _, err := first.Write([]byte("after")); err == nil {
+		t.Error("sink of the replaced logger is still open")
+	}
+}
+
+func TestDatagramWriterBackoff(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "collector.sock")
+	w := newDatagramWriter("unixgram", path)
+	defer w.Close()
+
+	_, err := w.Write([]byte("unreachable"))
+	if err == nil {
+		t.Fatal("write without a receiver succeeded")
+	}
+
+	receiver, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer receiver.Close()
+
+	// Still backing off, the receiver isn't dialed yet
+	_, err = w.Write([]byte("backing off"))
+	if err == nil {
+		t.Error("write while backing off redialed")
+	}
+
+	time.Sleep(datagramMinBackoff + 20*time.Millisecond)
+
+	_, err = w.Write([]byte("reachable"))
+	if err != nil {
+		t.Fatalf("write after the backoff failed: %v", err)
+	}
+
+	buf := make([]byte, 64)
+	receiver.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := receiver.Read(buf)
+	if err != nil || string(buf[:n]) != "reachable" {
+		t.Errorf("received %q, %v", buf[:n], err)
+	}
+}
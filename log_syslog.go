@@ -0,0 +1,127 @@
+package october
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Syslog facility codes by name, see RFC 5424
+var syslogFacilities = map[string]int{
+	"kern":     0,
+	"user":     1,
+	"mail":     2,
+	"daemon":   3,
+	"auth":     4,
+	"syslog":   5,
+	"lpr":      6,
+	"news":     7,
+	"uucp":     8,
+	"cron":     9,
+	"authpriv": 10,
+	"ftp":      11,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
+
+// Syslog severity of a zap level
+func syslogSeverity(level zapcore.Level) int {
+	switch level {
+	case zapcore.DebugLevel:
+		return 7
+	case zapcore.InfoLevel:
+		return 6
+	case zapcore.WarnLevel:
+		return 4
+	case zapcore.ErrorLevel:
+		return 3
+	}
+
+	return 2
+}
+
+// Writes entries as syslog messages, with the entry level as the message severity.
+// Local sockets use the traditional format, remote servers also receive a timestamp and hostname.
+type syslogCore struct {
+	zapcore.LevelEnabler
+	encoder  zapcore.Encoder
+	out      *datagramWriter
+	local    bool
+	facility int
+	tag      string
+	hostname string
+	pid      int
+}
+
+func newSyslogCore(sink LogSink, encoder zapcore.Encoder, enabler zapcore.LevelEnabler) *syslogCore {
+	core := &syslogCore{
+		LevelEnabler: enabler,
+		encoder:      encoder,
+		local:        sink.Address == "",
+		facility:     sink.Facility,
+		tag:          sink.Tag,
+		pid:          os.Getpid(),
+	}
+
+	if core.local {
+		core.out = newDatagramWriter("unixgram", sink.Path)
+	} else {
+		core.out = newDatagramWriter("udp", sink.Address)
+		core.hostname, _ = os.Hostname()
+	}
+
+	return core
+}
+
+func (c *syslogCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.encoder = c.encoder.Clone()
+	for _, field := range fields {
+		field.AddTo(clone.encoder)
+	}
+
+	return &clone
+}
+
+func (c *syslogCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+
+	return checked
+}
+
+func (c *syslogCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+
+	priority := c.facility*8 + syslogSeverity(entry.Level)
+	message := strings.TrimRight(buf.String(), "\n")
+
+	var line string
+	if c.local {
+		line = fmt.Sprintf("<%d>%s %s[%d]: %s", priority, entry.Time.Format(time.Stamp), c.tag, c.pid, message)
+	} else {
+		line = fmt.Sprintf("<%d>%s %s %s[%d]: %s", priority, entry.Time.Format(time.RFC3339), c.hostname, c.tag, c.pid, message)
+	}
+
+	_, err = c.out.Write([]byte(line))
+
+	return err
+}
+
+func (c *syslogCore) Sync() error {
+	return nil
+}
@@ -1,28 +1,43 @@
 package october
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
+	"reflect"
 	"strings"
+	"sync"
+
+	"github.com/mitchellh/mapstructure"
+	"go.uber.org/zap/zapcore"
 )
 
-// Modes define October behavior across configurations
+// Modes define October behavior across configurations, see ModePolicy
 type Mode uint8
 
 func (m Mode) String() string {
-	switch m {
-	case LOCAL:
-		return "LOCAL"
-	case DEV:
-		return "DEV"
-	case STAGE:
-		return "STAGE"
-	case PROD:
-		return "PROD"
+	modeRegistryLock.RLock()
+	defer modeRegistryLock.RUnlock()
+
+	if int(m) < len(modeRegistry) {
+		return modeRegistry[m].name
 	}
 
 	return "UNKNOWN"
 }
 
+// Returns the behavior of the mode. Unregistered modes get the PROD policy, the most conservative.
+func (m Mode) Policy() ModePolicy {
+	modeRegistryLock.RLock()
+	defer modeRegistryLock.RUnlock()
+
+	if int(m) < len(modeRegistry) {
+		return modeRegistry[m].policy
+	}
+
+	return modeRegistry[PROD].policy
+}
+
 const (
 	LOCAL Mode = iota // Running locally
 	DEV               // Running in development, the inference here is "development remotely"
@@ -30,21 +45,217 @@ const (
 	PROD              // Running in production
 )
 
-// Returns the mode from environment variable, as well as if it was found or not
+// Describes how October behaves in a mode
+type ModePolicy struct {
+	DebugEndpoints       bool // Serve pprof under /debug/pprof/ and buffered logs on /debug/logs on the October server
+	GraphQLPlayground    bool // Serve the GraphQL playground at /
+	GraphQLIntrospection bool // Allow GraphQL introspection queries
+	GinDebug             bool // Run gin in debug mode
+	PayloadLogging       bool // Log GRPC request and response payloads
+	ErrorDetail          bool // Return internal error messages to GraphQL clients
+
+	LogLevel       zapcore.Level
+	LogEncoding    string // One of the LogEncoding* encodings
+	LogDevelopment bool   // zap development mode, see zap.Config
+	LogSampling    bool   // Sample repeated log entries, see zap.SamplingConfig
+	LogBuffer      int    // Recent log entries kept in memory for /debug/logs, 0 disables, see LogBuffer
+}
+
+type registeredMode struct {
+	name   string
+	policy ModePolicy
+}
+
+var (
+	modeRegistryLock sync.RWMutex
+
+	// Indexed by Mode
+	modeRegistry = []registeredMode{
+		LOCAL: {name: "LOCAL", policy: ModePolicy{
+			DebugEndpoints:       true,
+			GraphQLPlayground:    true,
+			GraphQLIntrospection: true,
+			GinDebug:             true,
+			PayloadLogging:       true,
+			ErrorDetail:          true,
+			LogLevel:             zapcore.DebugLevel,
+			LogEncoding:          "console",
+			LogDevelopment:       true,
+			LogBuffer:            1000,
+		}},
+		DEV: {name: "DEV", policy: ModePolicy{
+			DebugEndpoints:       true,
+			GraphQLIntrospection: true,
+			ErrorDetail:          true,
+			LogLevel:             zapcore.DebugLevel,
+			LogEncoding:          "json",
+			LogDevelopment:       true,
+			LogBuffer:            1000,
+		}},
+		STAGE: {name: "STAGE", policy: ModePolicy{
+			DebugEndpoints:       true,
+			GraphQLIntrospection: true,
+			ErrorDetail:          true,
+			LogLevel:             zapcore.InfoLevel,
+			LogEncoding:          "json",
+			LogSampling:          true,
+		}},
+		PROD: {name: "PROD", policy: ModePolicy{
+			GraphQLIntrospection: true,
+			ErrorDetail:          true,
+			LogLevel:             zapcore.InfoLevel,
+			LogEncoding:          "json",
+			LogSampling:          true,
+		}},
+	}
+)
+
+// Register a custom mode, such as QA or PERF, parsed by ModeFromEnv from its name
+func RegisterMode(name string, policy ModePolicy) (Mode, error) {
+	name = strings.TrimSpace(strings.ToUpper(name))
+	if name == "" {
+		return 0, fmt.Errorf("Mode name must not be empty")
+	}
+
+	modeRegistryLock.Lock()
+	defer modeRegistryLock.Unlock()
+
+	for _, registered := range modeRegistry {
+		if registered.name == name {
+			return 0, fmt.Errorf("Mode %s is already registered", name)
+		}
+	}
+
+	if len(modeRegistry) > int(^Mode(0)) {
+		return 0, fmt.Errorf("Too many modes registered")
+	}
+
+	modeRegistry = append(modeRegistry, registeredMode{name: name, policy: policy})
+
+	return Mode(len(modeRegistry) - 1), nil
+}
+
+func MustRegisterMode(name string, policy ModePolicy) Mode {
+	mode, err := RegisterMode(name, policy)
+	if err != nil {
+		panic(err)
+	}
+
+	return mode
+}
+
+// Replace the policy of a registered mode, including the built in modes
+func SetModePolicy(mode Mode, policy ModePolicy) error {
+	modeRegistryLock.Lock()
+	defer modeRegistryLock.Unlock()
+
+	if int(mode) >= len(modeRegistry) {
+		return fmt.Errorf("Mode %d is not registered", mode)
+	}
+
+	modeRegistry[mode].policy = policy
+
+	return nil
+}
+
+// Returns every registered mode
+func Modes() []Mode {
+	modeRegistryLock.RLock()
+	defer modeRegistryLock.RUnlock()
+
+	modes := make([]Mode, len(modeRegistry))
+	for i := range modeRegistry {
+		modes[i] = Mode(i)
+	}
+
+	return modes
+}
+
+// Returns the mode from environment variable, as well as if it was found or not.
+// Unrecognized values return LOCAL, use ParseMode to reject them.
 func ModeFromEnv() (Mode, bool) {
 
-	envVal := strings.TrimSpace(strings.ToUpper(os.Getenv(modeEnvVariable)))
+	return parseModeName(os.Getenv(modeEnvVariable))
+}
+
+// Returns the registered mode matching name, case insensitive, or an error if there isn't one
+func ParseMode(name string) (Mode, error) {
+	mode, ok := parseModeName(name)
+	if !ok {
+		var names []string
+		for _, m := range Modes() {
+			names = append(names, m.String())
+		}
+
+		return LOCAL, fmt.Errorf("Unrecognized mode %q, expected one of %s", name, strings.Join(names, ", "))
+	}
+
+	return mode, nil
+}
+
+func (m Mode) MarshalText() ([]byte, error) {
+	name := m.String()
+	if name == "UNKNOWN" {
+		return nil, fmt.Errorf("Mode %d is not registered", m)
+	}
+
+	return []byte(name), nil
+}
+
+func (m *Mode) UnmarshalText(text []byte) error {
+	mode, err := ParseMode(string(text))
+	if err != nil {
+		return err
+	}
+
+	*m = mode
+	return nil
+}
 
-	switch envVal {
-	case "LOCAL":
-		return LOCAL, true
-	case "DEV":
-		return DEV, true
-	case "STAGE":
-		return STAGE, true
-	case "PROD":
-		return PROD, true
+func (m Mode) MarshalJSON() ([]byte, error) {
+	text, err := m.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(string(text))
+}
+
+func (m *Mode) UnmarshalJSON(data []byte) error {
+	var name string
+	err := json.Unmarshal(data, &name)
+	if err != nil {
+		return err
+	}
+
+	return m.UnmarshalText([]byte(name))
+}
+
+// Returns the registered mode matching name, or LOCAL and false if there isn't one
+func parseModeName(name string) (Mode, bool) {
+	name = strings.TrimSpace(strings.ToUpper(name))
+
+	modeRegistryLock.RLock()
+	defer modeRegistryLock.RUnlock()
+
+	for i, registered := range modeRegistry {
+		if registered.name == name {
+			return Mode(i), true
+		}
 	}
 
 	return LOCAL, false
 }
+
+// Decodes mode names into Mode, following ModeFromEnv in falling back to LOCAL for unknown names.
+// Config structs are otherwise decoded with Mode.UnmarshalText, rejecting unknown names.
+func lenientStringToModeHookFunc() mapstructure.DecodeHookFuncType {
+	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if f.Kind() != reflect.String || t != reflect.TypeOf(LOCAL) {
+			return data, nil
+		}
+
+		mode, _ := parseModeName(reflect.ValueOf(data).String())
+		return mode, nil
+	}
+}
@@ -0,0 +1,159 @@
+package october
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Registered once per test binary, as modes can't be unregistered
+var (
+	testQAPolicy = ModePolicy{DebugEndpoints: true, LogLevel: zapcore.WarnLevel, LogEncoding: LogEncodingLogfmt}
+	testQAMode   = MustRegisterMode(" testqa ", testQAPolicy)
+)
+
+func TestRegisterMode(t *testing.T) {
+	mode, policy := testQAMode, testQAPolicy
+
+	if mode.String() != "TESTQA" {
+		t.Errorf("got name %s, want TESTQA", mode)
+	}
+	if !reflect.DeepEqual(mode.Policy(), policy) {
+		t.Errorf("got policy %+v, want %+v", mode.Policy(), policy)
+	}
+
+	found := false
+	for _, m := range Modes() {
+		found = found || m == mode
+	}
+	if !found {
+		t.Errorf("%s missing from Modes()", mode)
+	}
+
+	t.Setenv(modeEnvVariable, "TestQA")
+	if got, ok := ModeFromEnv(); got != mode || !ok {
+		t.Errorf("ModeFromEnv() = %s, %t, want %s", got, ok, mode)
+	}
+
+	for _, name := range []string{"testQA", "prod", "", "  "} {
+		if _, err := RegisterMode(name, policy); err == nil {
+			t.Errorf("RegisterMode(%q) registered a duplicate or empty name", name)
+		}
+	}
+}
+
+func TestSetModePolicy(t *testing.T) {
+	original := STAGE.Policy()
+	defer SetModePolicy(STAGE, original)
+
+	policy := original
+	policy.GraphQLPlayground = true
+	policy.LogLevel = zapcore.DebugLevel
+
+	err := SetModePolicy(STAGE, policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(STAGE.Policy(), policy) {
+		t.Errorf("got policy %+v, want %+v", STAGE.Policy(), policy)
+	}
+	if PROD.Policy().GraphQLPlayground {
+		t.Error("policy of another mode changed")
+	}
+
+	err = SetModePolicy(Mode(len(Modes())), policy)
+	if err == nil {
+		t.Error("set the policy of an unregistered mode")
+	}
+}
+
+func TestUnregisteredMode(t *testing.T) {
+	mode := Mode(len(Modes()))
+
+	if mode.String() != "UNKNOWN" {
+		t.Errorf("got name %s, want UNKNOWN", mode)
+	}
+	if !reflect.DeepEqual(mode.Policy(), PROD.Policy()) {
+		t.Errorf("got policy %+v, want the PROD policy", mode.Policy())
+	}
+
+	t.Setenv(modeEnvVariable, "NOT_A_MODE")
+	if got, ok := ModeFromEnv(); got != LOCAL || ok {
+		t.Errorf("ModeFromEnv() = %s, %t, want LOCAL, false", got, ok)
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Mode
+		wantErr bool
+	}{
+		{"LOCAL", LOCAL, false},
+		{"dev", DEV, false},
+		{" Stage ", STAGE, false},
+		{"PROD", PROD, false},
+		{"TESTQA", testQAMode, false},
+		{"PRODUCTION", LOCAL, true},
+		{"UNKNOWN", LOCAL, true},
+		{"", LOCAL, true},
+	}
+
+	for _, test := range tests {
+		got, err := ParseMode(test.name)
+		if (err != nil) != test.wantErr || got != test.want {
+			t.Errorf("ParseMode(%q) = %s, %v, want %s, error %t", test.name, got, err, test.want, test.wantErr)
+		}
+	}
+}
+
+func TestModeTextAndJSON(t *testing.T) {
+	for _, mode := range Modes() {
+		text, err := mode.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var fromText Mode
+		err = fromText.UnmarshalText(text)
+		if err != nil || fromText != mode {
+			t.Errorf("%s: text %q unmarshaled to %s, %v", mode, text, fromText, err)
+		}
+
+		data, err := json.Marshal(map[string]Mode{"mode": mode})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := `{"mode":"` + mode.String() + `"}`; string(data) != want {
+			t.Errorf("got JSON %s, want %s", data, want)
+		}
+
+		var fromJSON map[string]Mode
+		err = json.Unmarshal(data, &fromJSON)
+		if err != nil || fromJSON["mode"] != mode {
+			t.Errorf("%s: JSON %s unmarshaled to %s, %v", mode, data, fromJSON["mode"], err)
+		}
+	}
+}
+
+func TestUnregisteredModeText(t *testing.T) {
+	if _, err := Mode(len(Modes())).MarshalText(); err == nil {
+		t.Error("marshaled an unregistered mode")
+	}
+	if _, err := json.Marshal(Mode(len(Modes()))); err == nil {
+		t.Error("marshaled an unregistered mode to JSON")
+	}
+
+	mode := STAGE
+	if err := mode.UnmarshalText([]byte("PRODUCTION")); err == nil || mode != STAGE {
+		t.Errorf("unmarshaled an unregistered mode name, got %s, %v", mode, err)
+	}
+	for _, data := range []string{`"PRODUCTION"`, `3`} {
+		if err := json.Unmarshal([]byte(data), &mode); err == nil {
+			t.Errorf("unmarshaled %s", data)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package october
+
+import (
+	"time"
+)
+
+// Every setting October itself reads, decoded from OCTOBER_* environment variables by OctoberConfigFromEnv.
+// May also be built programmatically, starting from DefaultOctoberConfig.
+type OctoberConfig struct {
+	Mode Mode `october:"mode" description:"October mode, one of LOCAL, DEV, STAGE, PROD or a registered custom mode"`
+
+	Port        int    `october:"port" default:"10010" validate:"min=1,max=65535" description:"Port of the October admin server, serving /health, /metrics and debug endpoints"`
+	BindAddress string `october:"bind_address" default:"0.0.0.0" description:"Bind address of the October admin server"`
+
+	ShutdownTimeout time.Duration `october:"shutdown_timeout" default:"30s" validate:"min=0s" description:"How long to wait for servers to stop gracefully, 0 waits forever"`
+
+	GRPC    GRPCConfig    `october:"grpc"`
+	GraphQL GraphQLConfig `october:"graphql"`
+	TLS     TLSConfig     `october:"tls"`
+	Log     LogConfig     `october:"log"`
+}
+
+type GRPCConfig struct {
+	Port        int    `october:"port" default:"10000" validate:"min=1,max=65535" description:"Port of the controlled GRPC server"`
+	BindAddress string `october:"bind_address" default:"0.0.0.0" description:"Bind address of the controlled GRPC server"`
+
+	Payloads GRPCPayloadConfig `october:"payloads"`
+}
+
+// Request and response payload logging of the controlled GRPC server
+type GRPCPayloadConfig struct {
+	Enabled      *bool    `october:"enabled" description:"Log request and response payloads, defaults to the mode's setting"`
+	Methods      []string `october:"methods" description:"Methods to log payloads of, comma separated, e.g. /pkg.Service/Method or /pkg.Service for every method of a service, empty logs every method"`
+	RedactFields []string `october:"redact_fields" description:"Proto field names redacted from payloads, comma separated, in addition to common names such as password and token"`
+	MaxSize      ByteSize `october:"max_size" default:"4KiB" description:"Payloads larger than this are truncated, 0 disables truncation"`
+}
+
+type GraphQLConfig struct {
+	Port        int    `october:"port" default:"8080" validate:"min=1,max=65535" description:"Port of the controlled GraphQL server"`
+	BindAddress string `october:"bind_address" default:"0.0.0.0" description:"Bind address of the controlled GraphQL server"`
+
+	ReadTimeout  time.Duration `october:"read_timeout" validate:"min=0s" description:"GraphQL server read timeout, 0 disables the timeout"`
+	WriteTimeout time.Duration `october:"write_timeout" validate:"min=0s" description:"GraphQL server write timeout, 0 disables the timeout"`
+}
+
+// TLS credential paths, both must be set to enable TLS
+type TLSConfig struct {
+	BundleCRT string `october:"bundle_crt" validate:"file" description:"Path of the TLS certificate bundle for the GRPC server"`
+	Key       string `october:"key" validate:"file" description:"Path of the TLS key for the GRPC server"`
+}
+
+// Overrides for the logger built for the mode, empty values keep the mode's defaults
+type LogConfig struct {
+	Level       string `october:"level" validate:"oneofci=debug info warn error dpanic panic fatal" description:"Minimum log level, defaults to the mode's level"`
+	Encoding    string `october:"encoding" validate:"oneofci=json console logfmt ecs gcp" description:"Log encoding, one of json, console, logfmt, ecs (Elastic Common Schema) or gcp (Google Cloud Logging), defaults to the mode's encoding"`
+	Development *bool  `october:"development" description:"zap development mode, defaults to the mode's setting"`
+
+	Sampling           *bool `october:"sampling" description:"Sample repeated log entries, defaults to the mode's setting"`
+	SamplingInitial    int   `october:"sampling_initial" default:"100" validate:"min=0" description:"Entries logged per second for each message before sampling"`
+	SamplingThereafter int   `october:"sampling_thereafter" default:"100" validate:"min=0" description:"Once sampling, log every Nth entry for each message"`
+
+	OutputPaths      []string `october:"output_paths" description:"Log outputs, comma separated, defaults to stdout"`
+	ErrorOutputPaths []string `october:"error_output_paths" description:"Outputs for internal logger errors, comma separated, defaults to stderr"`
+
+	DedupeWindow time.Duration `october:"dedupe_window" validate:"min=0s" description:"Drop entries identical to one logged within this window, logging how many repeated once it ends, 0 disables"`
+	RateLimit    int           `october:"rate_limit" validate:"min=0" description:"Entries per second allowed for each logger name and level, 0 disables"`
+	RateBurst    int           `october:"rate_burst" validate:"min=0" description:"Entries allowed at once above rate_limit, defaults to rate_limit"`
+
+	Buffer *int `october:"buffer" validate:"min=0" description:"Recent log entries kept in memory and served on /debug/logs of the admin server in modes with debug endpoints, 0 disables, defaults to the mode's setting"`
+
+	Sinks []string `october:"sinks" description:"Additional log outputs with their own minimum level, comma separated, e.g. file:///var/log/app/errors.log?level=error&max_size=100MB&max_backups=5, see LogSink"`
+
+	Caller          *bool             `october:"caller" description:"Include the calling file and line, defaults to true"`
+	TimeFormat      string            `october:"time_format" description:"One of iso8601, rfc3339, rfc3339nano, epoch, epoch_millis, epoch_nanos, or a Go time layout"`
+	Keys            map[string]string `october:"keys" description:"Renamed entry keys by role, e.g. message=msg,time=@t, roles are time, level, logger, caller, function, message and stacktrace, - omits the key"`
+	StacktraceLevel string            `october:"stacktrace_level" validate:"oneofci=debug info warn error dpanic panic fatal" description:"Minimum level to include stack traces, defaults to warn in development and error otherwise"`
+
+	GCPProject string `october:"gcp_project" description:"Google Cloud project ID, links request logs to Cloud Trace traces from X-Cloud-Trace-Context or traceparent headers, defaults to GOOGLE_CLOUD_PROJECT with the gcp encoding"`
+}
+
+// Returns an OctoberConfig with every default applied, for the given mode
+func DefaultOctoberConfig(mode Mode) OctoberConfig {
+	var cfg OctoberConfig
+
+	// Defaults are constant tags, decoding them only fails if the tags themselves are broken
+	err := ApplyConfigDefaults(&cfg)
+	if err != nil {
+		panic(err)
+	}
+
+	cfg.Mode = mode
+
+	return cfg
+}
+
+// Decode OctoberConfig from OCTOBER_* environment variables, falling back to defaults.
+// An unrecognized OCTOBER_MODE falls back to LOCAL, as with ModeFromEnv.
+func OctoberConfigFromEnv() (OctoberConfig, error) {
+	var cfg OctoberConfig
+
+	configurator := NewEnvConfigurator()
+	configurator.WithDecodeHooks(lenientStringToModeHookFunc())
+
+	err := configurator.DecodeEnv(&cfg, octoberEnvPrefix)
+
+	return cfg, err
+}
+
+func MustOctoberConfigFromEnv() OctoberConfig {
+	cfg, err := OctoberConfigFromEnv()
+	if err != nil {
+		panic(err)
+	}
+
+	return cfg
+}
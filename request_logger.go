@@ -0,0 +1,116 @@
+package october
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+const (
+	RequestIDHeader      = "X-Request-ID" // HTTP header carrying the correlation ID of a request
+	RequestIDMetadataKey = "x-request-id" // GRPC metadata key carrying the correlation ID of a call
+	RequestIDField       = "request_id"   // Log field holding the correlation ID
+)
+
+// Longest request ID accepted from clients, longer IDs are replaced with a generated ID
+const maxRequestIDLength = 128
+
+type requestLoggerKey struct{}
+type requestIDKey struct{}
+
+// Returns the request-scoped logger stored in ctx, or the global logger if there isn't one.
+// Loggers stored by October's gin middleware and GRPC interceptors include the request ID.
+func Logger(ctx context.Context) *zap.Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(requestLoggerKey{}).(*zap.Logger); ok && logger != nil {
+			return logger
+		}
+	}
+
+	return zap.L()
+}
+
+// Same as Logger, returning a sugared logger
+func SugaredLogger(ctx context.Context) *zap.SugaredLogger {
+	return Logger(ctx).Sugar()
+}
+
+// Returns a copy of ctx storing logger, retrieved with Logger
+func WithLogger(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, requestLoggerKey{}, logger)
+}
+
+// Returns the correlation ID of the request ctx belongs to, empty if there isn't one
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Returns a copy of ctx storing the request ID id, retrieved with RequestID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// Generates a random 128 bit request ID, hex encoded
+func NewRequestID() string {
+	id := make([]byte, 16)
+
+	// crypto/rand only fails if the OS can't provide randomness, which leaves nothing useful to fall back to
+	_, err := rand.Read(id)
+	if err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(id)
+}
+
+// Returns id if it's usable as a request ID, otherwise a new request ID.
+// IDs from clients are logged and echoed, so they're limited to a reasonable length of printable ASCII.
+func requestIDOrNew(id string) string {
+	if id == "" || len(id) > maxRequestIDLength {
+		return NewRequestID()
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return NewRequestID()
+		}
+	}
+
+	return id
+}
+
+// Returns a copy of ctx storing id and logger with the id and any other fields added
+func withRequestLogger(ctx context.Context, logger *zap.Logger, id string, fields ...zap.Field) context.Context {
+	ctx = WithRequestID(ctx, id)
+	return WithLogger(ctx, logger.With(append([]zap.Field{zap.String(RequestIDField, id)}, fields...)...))
+}
+
+// Project request logs are linked to Cloud Trace traces in, set by ConfigureZap, see LogConfig.GCPProject
+var requestTraceProject atomic.Value
+
+func setRequestTraceProject(project string) {
+	requestTraceProject.Store(project)
+}
+
+// Returns fields linking a request's entries to its Cloud Trace trace, from X-Cloud-Trace-Context or traceparent values.
+// Returns no fields if request logs aren't linked to Cloud Trace, or the request has no trace.
+func requestTraceFields(traceContext, traceparent string) []zap.Field {
+	project, _ := requestTraceProject.Load().(string)
+	if project == "" {
+		return nil
+	}
+
+	if fields := GCPTraceFields(project, traceContext); len(fields) > 0 {
+		return fields
+	}
+
+	return GCPTraceparentFields(project, traceparent)
+}
@@ -0,0 +1,279 @@
+package october
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Layout of the timestamp added to rotated file names, e.g. app-2020-01-02T15-04-05.000.log.
+// Files rotated within the same millisecond get a sequence number after the timestamp, e.g. app-2020-01-02T15-04-05.000-1.log
+const rotatedFileTimeLayout = "2006-01-02T15-04-05.000"
+
+// When a RotatingFile rotates and how many rotated files it keeps, zero values disable each setting
+type RotateOptions struct {
+	MaxSize    ByteSize      // Rotate before a write would grow the file past this size
+	Interval   time.Duration // Rotate when the wall clock crosses a multiple of the interval, e.g. 24h rotates at midnight UTC
+	MaxBackups int           // Rotated files to keep
+	MaxAge     time.Duration // Remove rotated files older than this
+}
+
+// A log file rotated by size and/or time, usable as a zapcore.WriteSyncer.
+// Rotated files are renamed with a timestamp and kept alongside the active file.
+type RotatingFile struct {
+	lock    sync.Mutex
+	path    string
+	options RotateOptions
+
+	file     *os.File
+	size     int64
+	openedAt time.Time
+}
+
+func NewRotatingFile(path string, options RotateOptions) (*RotatingFile, error) {
+	r := &RotatingFile{
+		path:    path,
+		options: options,
+	}
+
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return nil, errors.Wrap(err, "log directory")
+	}
+
+	err = r.open()
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.file == nil {
+		return 0, errors.Errorf("%s is closed", r.path)
+	}
+
+	if r.shouldRotate(int64(len(p)), time.Now()) {
+		err := r.rotate()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+
+	return n, err
+}
+
+func (r *RotatingFile) Sync() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+
+	return r.file.Sync()
+}
+
+func (r *RotatingFile) Close() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+
+	err := r.file.Close()
+	r.file = nil
+
+	return err
+}
+
+// Rotate the file now, regardless of its size or age
+func (r *RotatingFile) Rotate() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.file == nil {
+		return errors.Errorf("%s is closed", r.path)
+	}
+
+	return r.rotate()
+}
+
+func (r *RotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	r.file = file
+	r.size = info.Size()
+	r.openedAt = time.Now()
+
+	// An existing file continues from when it was last written
+	if r.size > 0 {
+		r.openedAt = info.ModTime()
+	}
+
+	return nil
+}
+
+func (r *RotatingFile) shouldRotate(writeSize int64, now time.Time) bool {
+	// Never rotate an empty file, a single write larger than MaxSize is written as is
+	if r.size == 0 {
+		return false
+	}
+
+	if r.options.MaxSize > 0 && r.size+writeSize > int64(r.options.MaxSize) {
+		return true
+	}
+
+	if r.options.Interval > 0 && !now.Truncate(r.options.Interval).Equal(r.openedAt.Truncate(r.options.Interval)) {
+		return true
+	}
+
+	return false
+}
+
+func (r *RotatingFile) rotate() error {
+	err := r.file.Close()
+	r.file = nil
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(r.path, r.backupName(time.Now()))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	err = r.open()
+	if err != nil {
+		return err
+	}
+
+	return r.removeOldBackups()
+}
+
+func (r *RotatingFile) backupName(t time.Time) string {
+	dir, name := filepath.Split(r.path)
+	ext := filepath.Ext(name)
+	base := filepath.Join(dir, strings.TrimSuffix(name, ext)+"-"+t.UTC().Format(rotatedFileTimeLayout))
+
+	backup := base + ext
+	for seq := 1; ; seq++ {
+		if _, err := os.Lstat(backup); os.IsNotExist(err) {
+			return backup
+		}
+		backup = fmt.Sprintf("%s-%d%s", base, seq, ext)
+	}
+}
+
+// A rotated file of a RotatingFile
+type rotatedFile struct {
+	path      string
+	rotatedAt time.Time
+	seq       int // Orders files rotated within the same millisecond
+}
+
+// Returns rotated files of this file, newest first
+func (r *RotatingFile) backups() ([]rotatedFile, error) {
+	dir, name := filepath.Split(r.path)
+	ext := filepath.Ext(name)
+	prefix := strings.TrimSuffix(name, ext) + "-"
+
+	if dir == "" {
+		dir = "."
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []rotatedFile
+	for _, entry := range entries {
+		entryName := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(entryName, prefix) || !strings.HasSuffix(entryName, ext) {
+			continue
+		}
+
+		stamp := strings.TrimSuffix(strings.TrimPrefix(entryName, prefix), ext)
+		if len(stamp) < len(rotatedFileTimeLayout) {
+			continue
+		}
+
+		rotatedAt, err := time.Parse(rotatedFileTimeLayout, stamp[:len(rotatedFileTimeLayout)])
+		if err != nil {
+			continue
+		}
+
+		seq := 0
+		if suffix := stamp[len(rotatedFileTimeLayout):]; suffix != "" {
+			_, err := fmt.Sscanf(suffix, "-%d", &seq)
+			if err != nil || fmt.Sprintf("-%d", seq) != suffix {
+				continue
+			}
+		}
+
+		backups = append(backups, rotatedFile{path: filepath.Join(dir, entryName), rotatedAt: rotatedAt, seq: seq})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].rotatedAt.Equal(backups[j].rotatedAt) {
+			return backups[i].rotatedAt.After(backups[j].rotatedAt)
+		}
+		return backups[i].seq > backups[j].seq
+	})
+
+	return backups, nil
+}
+
+func (r *RotatingFile) removeOldBackups() error {
+	if r.options.MaxBackups <= 0 && r.options.MaxAge <= 0 {
+		return nil
+	}
+
+	backups, err := r.backups()
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-r.options.MaxAge)
+
+	for i, backup := range backups {
+		remove := r.options.MaxBackups > 0 && i >= r.options.MaxBackups
+
+		if !remove && r.options.MaxAge > 0 {
+			remove = backup.rotatedAt.Before(cutoff)
+		}
+
+		if remove {
+			err := os.Remove(backup.path)
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,204 @@
+package october
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotatingFileShouldRotate(t *testing.T) {
+	opened := time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		options   RotateOptions
+		size      int64
+		writeSize int64
+		now       time.Time
+		want      bool
+	}{
+		{"no options", RotateOptions{}, 1 << 30, 100, opened.Add(48 * time.Hour), false},
+		{"under max size", RotateOptions{MaxSize: 100}, 50, 50, opened, false},
+		{"over max size", RotateOptions{MaxSize: 100}, 50, 51, opened, true},
+		{"empty file over max size", RotateOptions{MaxSize: 100}, 0, 500, opened, false},
+		{"same interval", RotateOptions{Interval: 24 * time.Hour}, 10, 10, opened.Add(8 * time.Hour), false},
+		{"next interval", RotateOptions{Interval: 24 * time.Hour}, 10, 10, opened.Add(9 * time.Hour), true},
+		{"empty file next interval", RotateOptions{Interval: time.Hour}, 0, 10, opened.Add(2 * time.Hour), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := &RotatingFile{options: test.options, size: test.size, openedAt: opened}
+
+			if got := r.shouldRotate(test.writeSize, test.now); got != test.want {
+				t.Errorf("got %t, want %t", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRotatingFileRotate(t *testing.T) {
+	tests := []struct {
+		name      string
+		options   RotateOptions
+		writes    []string
+		rotations int // Extra rotations with Rotate, after the writes
+		active    string
+		backups   []string // Newest first
+	}{
+		{
+			name:    "max size",
+			options: RotateOptions{MaxSize: 10},
+			writes:  []string{"aaaaaa", "bbbbbb", "cccccc"},
+			active:  "cccccc",
+			backups: []string{"bbbbbb", "aaaaaa"},
+		},
+		{
+			name:    "max backups",
+			options: RotateOptions{MaxSize: 10, MaxBackups: 1},
+			writes:  []string{"aaaaaa", "bbbbbb", "cccccc"},
+			active:  "cccccc",
+			backups: []string{"bbbbbb"},
+		},
+		{
+			name:      "same millisecond",
+			writes:    []string{"aaaaaa"},
+			rotations: 3,
+			active:    "",
+			backups:   []string{"", "", "aaaaaa"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "app.log")
+
+			r, err := NewRotatingFile(path, test.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+
+			for _, write := range test.writes {
+				_, err := r.Write([]byte(write))
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for i := 0; i < test.rotations; i++ {
+				err := r.Rotate()
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if active := readTestFile(t, path); active != test.active {
+				t.Errorf("active file has %q, want %q", active, test.active)
+			}
+
+			backups, err := r.backups()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(backups) != len(test.backups) {
+				t.Fatalf("got %d backups, want %d", len(backups), len(test.backups))
+			}
+
+			for i, backup := range backups {
+				if contents := readTestFile(t, backup.path); contents != test.backups[i] {
+					t.Errorf("backup %d %s has %q, want %q", i, filepath.Base(backup.path), contents, test.backups[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRotatingFileBackupNames(t *testing.T) {
+	dir := t.TempDir()
+	r := &RotatingFile{path: filepath.Join(dir, "app.log")}
+
+	at := time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC)
+	for _, name := range []string{
+		"app-2020-01-02T15-04-05.000.log",
+		"app-2020-01-02T15-04-05.000-1.log",
+		"app-2020-01-02T15-04-05.000-2.log",
+		"app-2020-01-02T15-04-04.000.log",
+		"app-errors.log",
+		"app-2020-01-02T15-04-05.000-x.log",
+	} {
+		writeTestFile(t, filepath.Join(dir, name), "")
+	}
+
+	if name := filepath.Base(r.backupName(at)); name != "app-2020-01-02T15-04-05.000-3.log" {
+		t.Errorf("got backup name %s for a taken timestamp, want the next sequence number", name)
+	}
+
+	backups, err := r.backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"app-2020-01-02T15-04-05.000-2.log",
+		"app-2020-01-02T15-04-05.000-1.log",
+		"app-2020-01-02T15-04-05.000.log",
+		"app-2020-01-02T15-04-04.000.log",
+	}
+	if len(backups) != len(want) {
+		t.Fatalf("got %d backups, want %d", len(backups), len(want))
+	}
+	for i, backup := range backups {
+		if name := filepath.Base(backup.path); name != want[i] {
+			t.Errorf("backup %d is %s, want %s", i, name, want[i])
+		}
+	}
+}
+
+func TestRotatingFileMaxAge(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	old := filepath.Join(dir, "app-"+time.Now().Add(-2*time.Hour).UTC().Format(rotatedFileTimeLayout)+".log")
+	writeTestFile(t, old, "old")
+
+	r, err := NewRotatingFile(path, RotateOptions{MaxAge: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	_, err = r.Write([]byte("current"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.Rotate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Error("backup older than MaxAge not removed")
+	}
+
+	backups, err := r.backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 || readTestFile(t, backups[0].path) != "current" {
+		t.Errorf("got backups %+v, want only the latest", backups)
+	}
+}
+
+func readTestFile(t *testing.T, path string) string {
+	t.Helper()
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(contents)
+}
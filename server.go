@@ -6,13 +6,10 @@ import (
 	"net/http"
 	"net/http/pprof"
 	"os"
-	"strings"
 	"sync"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
-	"strconv"
-
 	"go.uber.org/zap"
 )
 
@@ -56,24 +53,31 @@ func WithHealthCheck(o *OctoberServer, name string, hc HealthCheck) *OctoberServ
 
 func NewOctoberServer(mode Mode, port int) *OctoberServer {
 
-	if port == 0 {
-		port = 10010
+	cfg := DefaultOctoberConfig(mode)
+	if port != 0 {
+		cfg.Port = port
 	}
 
+	return NewOctoberServerWithConfig(cfg)
+}
+
+func NewOctoberServerWithConfig(cfg OctoberConfig) *OctoberServer {
+
 	logger := zap.S().Named("OCTOBER")
 	// /healthChecks.AddCheck("october", check)
 
-	logger.Infof("Configuring server with mode %s", mode)
+	logger.Infof("Configuring server with mode %s", cfg.Mode)
 
 	return &OctoberServer{
 		logger: logger,
 		server:       &http.Server{},
-		mode:         mode,
+		mode:         cfg.Mode,
+		config:       cfg,
 		healthChecks: make(HealthChecks),
 		checkLock:    &sync.Mutex{},
 
-		octoberBindAddress: "0.0.0.0",
-		octoberBindPort:    port,
+		octoberBindAddress: cfg.BindAddress,
+		octoberBindPort:    cfg.Port,
 	}
 }
 
@@ -81,6 +85,7 @@ type OctoberServer struct {
 	logger   *zap.SugaredLogger
 	server                     *http.Server
 	mode                       Mode
+	config                     OctoberConfig
 	healthChecks               HealthChecks
 	checkLock                  *sync.Mutex // We only want 1 check to go on at once
 	lastHealthResult           HealthCheckResult
@@ -100,8 +105,17 @@ func (o *OctoberServer) buildServerMux() *http.ServeMux {
 	mux.Handle("/metrics", promhttp.Handler())
 	//mux.Handle("/influxdb", metrichttp.InfluxDBHandler())
 	mux.HandleFunc("/health", healthHTTPHandler(o.healthChecks))
+	mux.HandleFunc("/debug/loglevel", logLevelHTTPHandler())
 
-	if o.mode != PROD {
+	if o.mode.Policy().DebugEndpoints {
+		// Log tails only end when their client disconnects, so they're ended when the server shuts down instead of holding it up
+		tailsDone := make(chan struct{})
+		closeTails := &sync.Once{}
+		o.server.RegisterOnShutdown(func() {
+			closeTails.Do(func() { close(tailsDone) })
+		})
+
+		mux.HandleFunc("/debug/logs", logBufferHTTPHandler(tailsDone))
 		mux.HandleFunc("/debug/pprof/", pprof.Index)
 		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
 		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
@@ -117,42 +131,37 @@ func (o *OctoberServer) GenerateGRPCServerFromEnv() (*GRPCServer, error) {
 
 	zap.L().Named("OCTOBER").Info("Generating controlled GRPC from environment variables")
 
-	address := "0.0.0.0"
-	port := 10000
-
-	envPort := strings.TrimSpace(os.Getenv(grpcPortEnvVariable))
-	if envPort != "" {
-		var err error
-		port, err = strconv.Atoi(envPort)
-		if err != nil {
-			return nil, err
-		}
+	cfg, err := OctoberConfigFromEnv()
+	if err != nil {
+		return nil, err
 	}
 
-	tlsCrt := strings.TrimSpace(os.Getenv(tlsBundleCRTEnvVariable))
-	tlsKey := strings.TrimSpace(os.Getenv(tlsKeyEnvVariable))
+	return o.generateGRPCServer(cfg)
+}
 
-	if tlsCrt == "" {
-		o.logger.Infof("%s: (empty)", tlsBundleCRTEnvVariable)
-	} else {
-		o.logger.Infof("%s: %s", tlsBundleCRTEnvVariable, tlsCrt)
-	}
+// Generate a controlled GRPC server from the config this OctoberServer was created with
+func (o *OctoberServer) GenerateGRPCServer() (*GRPCServer, error) {
 
-	if tlsKey == "" {
-		o.logger.Infof("%s: (empty)", tlsKeyEnvVariable)
-	} else {
-		o.logger.Infof("%s: %s", tlsKeyEnvVariable, tlsKey)
-	}
+	zap.L().Named("OCTOBER").Info("Generating controlled GRPC from config")
+
+	return o.generateGRPCServer(o.config)
+}
+
+func (o *OctoberServer) generateGRPCServer(cfg OctoberConfig) (*GRPCServer, error) {
+
+	o.logger.Desugar().Info("Controlled GRPC config", ConfigZapFields(&cfg.TLS, octoberEnvPrefix+"_TLS")...)
 
 	server := &GRPCServer{
 		mode:   o.mode,
 		Server: nil,
 
-		address: address,
-		port:    port,
+		address: cfg.GRPC.BindAddress,
+		port:    cfg.GRPC.Port,
+
+		payloads: cfg.GRPC.Payloads,
 	}
 
-	tlsErr := server.WithTLS(tlsCrt, tlsKey)
+	tlsErr := server.WithTLS(cfg.TLS.BundleCRT, cfg.TLS.Key)
 	if tlsErr != nil {
 		return nil, tlsErr
 	}
@@ -165,30 +174,35 @@ func (o *OctoberServer) GenerateGQLGenServerServerFromEnv() (*GQLGenServer, erro
 
 	o.logger.Info("Generating controlled gqlgen server from environment variables")
 
-	address := "0.0.0.0"
-	port := 8080
-
-	envPort := strings.TrimSpace(os.Getenv(gqlPortEnvVariable))
-	if envPort != "" {
-		var err error
-		port, err = strconv.Atoi(envPort)
-		if err != nil {
-			return nil, err
-		}
+	cfg, err := OctoberConfigFromEnv()
+	if err != nil {
+		return nil, err
 	}
 
+	return o.generateGQLGenServer(cfg), nil
+}
+
+// Generate a controlled gqlgen server from the config this OctoberServer was created with
+func (o *OctoberServer) GenerateGQLGenServer() *GQLGenServer {
 
-	server := &GQLGenServer{
+	o.logger.Info("Generating controlled gqlgen server from config")
+
+	return o.generateGQLGenServer(o.config)
+}
+
+func (o *OctoberServer) generateGQLGenServer(cfg OctoberConfig) *GQLGenServer {
+
+	return &GQLGenServer{
 		mode:   o.mode,
 
 		serverLock: &sync.Mutex{},
 		healthChecks: o.healthChecks,
-		address: address,
-		port:    port,
-	}
-
-	return server, nil
+		address: cfg.GraphQL.BindAddress,
+		port:    cfg.GraphQL.Port,
 
+		readTimeout:  cfg.GraphQL.ReadTimeout,
+		writeTimeout: cfg.GraphQL.WriteTimeout,
+	}
 }
 
 func (o *OctoberServer) MustGenerateGQLGenServerServerFromEnv() *GQLGenServer {
@@ -293,6 +307,11 @@ func (o *OctoberServer) Start(controllableServers []ControllableServer, graceful
 	case <-stopChan:
 
 		shutdownCtx := context.Background()
+		if o.config.ShutdownTimeout > 0 {
+			var cancel context.CancelFunc
+			shutdownCtx, cancel = context.WithTimeout(shutdownCtx, o.config.ShutdownTimeout)
+			defer cancel()
+		}
 
 		// We received stop signal, call shutdown on all of our controllable servers
 		for _, controllable := range controllableServers {
@@ -0,0 +1,112 @@
+package october
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// Returns a port nothing is listening on
+func freeTestPort(t *testing.T) int {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+
+	return lis.Addr().(*net.TCPAddr).Port
+}
+
+// A controllable server that runs until stop is closed, then stops October
+type stopTestServer struct {
+	stop chan struct{}
+}
+
+func (s *stopTestServer) Name() string {
+	return "stopper"
+}
+
+func (s *stopTestServer) Start() (bool, error) {
+	<-s.stop
+	return true, nil
+}
+
+func (s *stopTestServer) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+func TestStartShutdownTimeoutWithOpenStream(t *testing.T) {
+	cfg := DefaultOctoberConfig(PROD)
+	cfg.BindAddress = "127.0.0.1"
+	cfg.Port = freeTestPort(t)
+	cfg.GRPC.BindAddress = "127.0.0.1"
+	cfg.GRPC.Port = freeTestPort(t)
+	cfg.ShutdownTimeout = 200 * time.Millisecond
+
+	o := NewOctoberServerWithConfig(cfg)
+	grpcServer, err := o.GenerateGRPCServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A stream that stays open until the server cancels it
+	opened := make(chan struct{})
+	grpcServer.Server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "octobertest.Hold",
+		HandlerType: (*interface{})(nil),
+		Streams: []grpc.StreamDesc{{
+			StreamName:    "Hold",
+			ServerStreams: true,
+			ClientStreams: true,
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				close(opened)
+				<-stream.Context().Done()
+				return stream.Context().Err()
+			},
+		}},
+	}, struct{}{})
+
+	stopper := &stopTestServer{stop: make(chan struct{})}
+	stopped := make(chan struct{})
+	go func() {
+		o.Start([]ControllableServer{grpcServer, stopper})
+		close(stopped)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, grpcServer.Address(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	_, err = conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true, ClientStreams: true}, "/octobertest.Hold/Hold", grpc.WaitForReady(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-opened:
+	case <-ctx.Done():
+		t.Fatal("stream never opened")
+	}
+
+	start := time.Now()
+	close(stopper.stop)
+
+	select {
+	case <-stopped:
+		if elapsed := time.Since(start); elapsed < cfg.ShutdownTimeout {
+			t.Errorf("stopped after %s, before the shutdown timeout with a stream open", elapsed)
+		}
+	case <-time.After(cfg.ShutdownTimeout + 3*time.Second):
+		t.Fatal("Start didn't return after the shutdown timeout with a stream open")
+	}
+}